@@ -1,10 +1,26 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"riscv-instruction-encoder/pkg/cache"
 	"riscv-instruction-encoder/pkg/decoder"
+	"riscv-instruction-encoder/pkg/delayslot"
+	"riscv-instruction-encoder/pkg/hazard"
+	"riscv-instruction-encoder/pkg/isa"
+	"riscv-instruction-encoder/pkg/memory"
+	"riscv-instruction-encoder/pkg/pk"
+	"riscv-instruction-encoder/pkg/predictor"
 	"riscv-instruction-encoder/pkg/runner"
+	"riscv-instruction-encoder/pkg/scoreboard"
+	"riscv-instruction-encoder/pkg/sim"
+	"riscv-instruction-encoder/pkg/symbols"
+	"riscv-instruction-encoder/pkg/tomasulo"
+	"riscv-instruction-encoder/pkg/trace"
+	"strings"
 )
 
 const (
@@ -15,9 +31,34 @@ const (
 const (
 	BIN_INSTRUCTION_FILE_NAME = "../../testdata/bin.txt"
 	HEX_INSTRUCTION_FILE_NAME = "../../testdata/hex.txt"
+	ASM_SOURCE_FILE_NAME      = "../../testdata/file.asm"
+	DELAY_SLOT_FILE_NAME      = "../../pkg/files/delay_slots.txt"
 )
 
-func main() {
+// loadSymbols reads labels from an assembly source, or the symbol table of
+// any other file assumed to be an ELF.
+func loadSymbols(path string) *symbols.Table {
+	if path == "" {
+		return nil
+	}
+
+	var table *symbols.Table
+	var err error
+	if strings.EqualFold(filepath.Ext(path), ".asm") || strings.EqualFold(filepath.Ext(path), ".s") {
+		table, err = symbols.LoadAsm(path)
+	} else {
+		table, err = symbols.LoadELF(path)
+	}
+	if err != nil {
+		fmt.Printf("Símbolos indisponíveis (%v), continuando sem rótulos.\n", err)
+		return nil
+	}
+	return table
+}
+
+// readProgram asks for the encoding of the input program and reads it from
+// path, or from the bundled test program of that encoding when path is empty.
+func readProgram(path string) []isa.RawInstruction {
 	var formatChoice string
 	fmt.Println("Select instruction format to decode (bin / hex):")
 	_, err := fmt.Scanln(&formatChoice)
@@ -41,7 +82,137 @@ func main() {
 		os.Exit(1)
 	}
 
-	encodedInstructions := decoder.DecodeFromFile(fileName, format)
+	if path != "" {
+		fileName = path
+	}
+	return decoder.DecodeFromFile(fileName, format)
+}
+
+func main() {
+	inputPath := flag.String("input", "", "program file to decode, e.g. a padded program written by a previous run (default testdata/bin.txt or testdata/hex.txt)")
+	symbolsPath := flag.String("symbols", ASM_SOURCE_FILE_NAME, "assembly source or ELF providing the program labels (not loaded by default with -input)")
+	simulate := flag.Bool("simulate", false, "run the program on the functional simulator and print the final state")
+	dynamic := flag.Bool("dynamic", false, "feed the pipeline the executed instruction stream instead of the static program order")
+	maxSteps := flag.Int("max-steps", 10000, "maximum number of instructions executed by the simulator")
+	misaligned := flag.String("misaligned", "trap", "misaligned load/store behaviour: trap, split or allow")
+	dataImage := flag.String("data", "", "$readmemh style hex image loaded into memory before the program runs")
+	elfPath := flag.String("elf", "", "RV32 ELF executable to run on the functional simulator (skips the pipeline analysis)")
+	cosimTrace := flag.String("cosim", "", "Spike --log-commits trace compared in lock-step with the simulator")
+	tracePath := flag.String("trace", "", "dynamic instruction trace (pc insn [T|N] or Spike commit log) analysed instead of the program")
+	explain := flag.Bool("explain", false, "write, for every run, a report telling why each NOP or stall was inserted, with counts per category, register and dependence")
+	diagramRows := flag.Int("diagram-rows", 200, "maximum number of instructions drawn in the pipeline diagrams (0 = all)")
+	interlock := flag.Bool("interlock", false, "also run every configuration with hardware interlocks instead of NOP insertion")
+	predictors := flag.String("predictors", "", "comma-separated branch predictors also evaluated on the control configurations: not-taken, btfn, 1bit[:n], 2bit[:n], btb[:n]; needs -dynamic or -trace")
+	pipelineName := flag.String("pipeline", "5stage", "pipeline topology: 5stage, 3stage, 7stage or a description file")
+	resolveStage := flag.String("resolve-stage", "", "stage at whose end branches and jumps resolve, e.g. id, ex or mem (default: the topology's)")
+	delaySlots := flag.Int("delay-slots", 0, "number of architectural branch delay slots; the program is rescheduled to fill them")
+	schedule := flag.Bool("schedule", false, "also run every configuration on the program reordered by the list scheduler and compare the overheads")
+	verify := flag.Bool("verify", false, "check that the program is hazard-free for -forwarding and -resolve-stage without modifying it, then exit")
+	forwarding := flag.Bool("forwarding", false, "pipeline verified by -verify has forwarding")
+	issueWidth := flag.Int("issue-width", 1, "instructions issued per cycle by an in-order superscalar front end")
+	pairMemory := flag.Int("pair-memory", 1, "loads and stores allowed in one issue bundle (0 = no limit)")
+	pairBranches := flag.Int("pair-branches", 1, "branches and jumps allowed in one issue bundle (0 = no limit)")
+	unitSpec := flag.String("units", "", "model the functional units of the execute stage with structural hazards: \"default\" or unit:count[:latency[:p|np]],... (units alu, mul, div, lsu, bru)")
+	unifiedMemory := flag.Bool("unified-memory", false, "also run every configuration with one memory port shared by fetch and loads/stores and compare the cycles with split memories")
+	icacheSpec := flag.String("icache", "", "instruction cache in front of fetch: \"default\" or key=value,... with size, line, ways, policy (lru|fifo|random), write (back|through), allocate (yes|no), penalty")
+	dcacheSpec := flag.String("dcache", "", "data cache in front of the memory stage, same format as -icache; needs -dynamic to know the addresses")
+	pathSpec := flag.String("forwarding-paths", "", "forwarding paths of the configurations with forwarding: \"all\" or a list of ex-ex (EX/MEM→EX), mem-ex (MEM/WB→EX), mem-mem (MEM/WB→MEM); reports the use of each path")
+	outOfOrder := flag.Bool("ooo", false, "also run the program on the Tomasulo out-of-order model and compare its IPC with the in-order pipeline")
+	robSize := flag.Int("rob", 8, "reorder buffer entries of the out-of-order model")
+	stations := flag.String("rs", "alu:3,mul:2,div:1,lsu:2,bru:2", "reservation stations of the out-of-order model, as n or unit:n,...")
+	scoreboardModel := flag.Bool("scoreboard", false, "also run the program on the CDC 6600 scoreboard model and compare its IPC with the in-order pipeline")
+	scoreboardUnits := flag.String("scoreboard-units", "alu:2,mul:1,div:1,lsu:1,bru:1", "functional units of the scoreboard model, as n or unit:n,...")
+	dump := flag.String("dump", "", "memory region printed after the simulation, as hexaddress:hexlength")
+	flag.Parse()
+
+	policy, err := memory.ParseMisalignedPolicy(*misaligned)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	opts := machineOptions{misaligned: policy, dataImage: *dataImage}
+
+	topology, err := isa.LoadTopology(*pipelineName)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	branchStage := topology.Resolve
+	if *resolveStage != "" {
+		branchStage, err = topology.ParseStage(*resolveStage)
+		// a branch cannot resolve before it reads its operands
+		earliest := topology.Points[isa.ClassBranch].Consume
+		if err != nil || branchStage < earliest || int(branchStage) >= topology.Len() {
+			fmt.Printf("Estágio de resolução inválido: %s (use um estágio entre %s e %s)\n", *resolveStage,
+				topology.StageName(earliest), topology.StageName(isa.Stage(topology.Len()-1)))
+			os.Exit(1)
+		}
+	}
+
+	var units *hazard.Units
+	if *unitSpec != "" {
+		parsed, err := hazard.ParseUnits(*unitSpec)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		units = &parsed
+	}
+
+	var paths *hazard.Paths
+	if *pathSpec != "" {
+		parsed, err := hazard.ParsePaths(*pathSpec)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		paths = &parsed
+	}
+
+	icache, err := parseCache(*icacheSpec)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	dcache, err := parseCache(*dcacheSpec)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	if *elfPath != "" {
+		machine := sim.NewMachine()
+		machine.Memory.Misaligned = policy
+		if err := machine.LoadELF(*elfPath); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		attachKernel(machine, *elfPath)
+		if *cosimTrace != "" {
+			os.Exit(runCosim(machine, *cosimTrace, *maxSteps))
+		}
+		os.Exit(runSimulation(machine, *maxSteps, *dump))
+	}
+
+	var encodedInstructions []isa.RawInstruction
+	var records []trace.Record
+	if *tracePath != "" {
+		records, err = trace.Load(*tracePath)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	} else {
+		encodedInstructions = readProgram(*inputPath)
+	}
+	// the labels of the bundled source do not match another program, such
+	// as a padded one fed back in, unless asked for
+	symbolsSet := false
+	flag.Visit(func(f *flag.Flag) { symbolsSet = symbolsSet || f.Name == "symbols" })
+	if *inputPath != "" && !symbolsSet {
+		*symbolsPath = ""
+	}
+	syms := loadSymbols(*symbolsPath)
 
 	executions := []struct {
 		forwarding           bool
@@ -57,14 +228,236 @@ func main() {
 		{true, true, true, "../../pkg/files/output_integrated_forwarding.txt"},
 	}
 
-	decodedInstructions := decoder.DecodeInstructionFromUInt32(encodedInstructions)
+	decodedInstructions := decoder.DecodeInstructionFromUInt32(encodedInstructions, syms)
+
+	if *verify {
+		if records != nil {
+			fmt.Println("A verificação exige um programa (sem -trace).")
+			os.Exit(1)
+		}
+		os.Exit(runVerify(decodedInstructions, hazard.Config{Forwarding: *forwarding, Paths: paths, ResolveStage: branchStage, Topology: topology, Units: units, StaticProgram: true}, syms))
+	}
+
+	if (*simulate || *cosimTrace != "") && records == nil {
+		machine, err := newMachine(encodedInstructions, opts)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		if *cosimTrace != "" {
+			os.Exit(runCosim(machine, *cosimTrace, *maxSteps))
+		}
+		os.Exit(runSimulation(machine, *maxSteps, *dump))
+	}
+
+	if *delaySlots > 0 {
+		if records != nil || *dynamic {
+			fmt.Println("Delay slots exigem o programa estático (sem -trace ou -dynamic).")
+			os.Exit(1)
+		}
+		decodedInstructions = scheduleDelaySlots(decodedInstructions, *delaySlots)
+	}
+	if *schedule && (records != nil || *dynamic || *delaySlots > 0) {
+		fmt.Println("O escalonamento exige o programa estático (sem -trace, -dynamic ou -delay-slots).")
+		os.Exit(1)
+	}
+
+	if *predictors != "" && records == nil && !*dynamic {
+		// the static program order falls through every branch and jump, so
+		// there is no real outcome to score a prediction against
+		fmt.Println("Os preditores exigem o fluxo de controle real (-dynamic ou -trace).")
+		os.Exit(1)
+	}
+	if (*outOfOrder || *scoreboardModel) && (records != nil || *dynamic) {
+		fmt.Println("Os modelos de escalonamento dinâmico exigem o programa estático (sem -trace ou -dynamic).")
+		os.Exit(1)
+	}
+
+	// each configuration runs once per variant: NOP insertion or hardware
+	// interlock, without prediction or with each requested predictor
+	type variant struct {
+		hardware  bool
+		predictor string
+	}
+	interlockModes := []bool{false}
+	if *interlock {
+		interlockModes = append(interlockModes, true)
+	}
+	var variants []variant
+	for _, hardware := range interlockModes {
+		variants = append(variants, variant{hardware: hardware})
+		if *predictors != "" {
+			for _, spec := range strings.Split(*predictors, ",") {
+				if _, err := predictor.Parse(spec); err != nil {
+					fmt.Println(err)
+					os.Exit(1)
+				}
+				variants = append(variants, variant{hardware: hardware, predictor: spec})
+			}
+		}
+	}
+
+	var comparisons []comparison
+	var portComparisons []portComparison
+	// the reference for the out-of-order model: integrated hazards with
+	// forwarding, no interlock and no predictor
+	var inOrder *runner.Pipeline
 	for _, exec := range executions {
-		runner.Run(
-			decodedInstructions,
-			exec.forwarding,
-			exec.dataHazardControl,
-			exec.controlHazardControl,
-			exec.fileName,
-		)
+		for _, v := range variants {
+			if v.predictor != "" && !exec.controlHazardControl {
+				continue
+			}
+			fileName := strings.TrimSuffix(exec.fileName, ".txt")
+			if *delaySlots > 0 {
+				fileName += fmt.Sprintf("_delay%d", *delaySlots)
+			}
+			if *issueWidth > 1 {
+				fileName += fmt.Sprintf("_w%d", *issueWidth)
+			}
+			if units != nil {
+				fileName += "_fu"
+			}
+			if paths != nil && exec.forwarding {
+				fileName += "_paths"
+			}
+			if icache != nil || dcache != nil {
+				fileName += "_cache"
+			}
+			if v.hardware {
+				fileName += "_interlock"
+			}
+			var pred predictor.Predictor
+			if v.predictor != "" {
+				// a fresh predictor per run, so no training leaks between runs
+				pred, _ = predictor.Parse(v.predictor)
+				fileName += "_pred_" + strings.ReplaceAll(pred.Name(), ":", "")
+			}
+			fileName += ".txt"
+
+			cfg := runner.Config{
+				Forwarding:         exec.forwarding,
+				ForwardingPaths:    paths,
+				DataHazard:         exec.dataHazardControl,
+				ControlHazard:      exec.controlHazardControl,
+				Interlock:          v.hardware,
+				Predictor:          pred,
+				BranchResolveStage: branchStage,
+				Topology:           topology,
+				Issue:              runner.IssueConfig{Width: *issueWidth, MemoryOps: *pairMemory, Branches: *pairBranches},
+				Units:              units,
+				ICache:             icache,
+				DCache:             dcache,
+				DelaySlots:         *delaySlots,
+				FilePath:           fileName,
+				Symbols:            syms,
+				MaxInstructions:    *maxSteps,
+				Trace:              records,
+				DiagramPath:        strings.Replace(strings.TrimSuffix(fileName, ".txt"), "output_", "diagram_", 1),
+				DiagramRows:        *diagramRows,
+				ProgramPath:        strings.Replace(strings.TrimSuffix(fileName, ".txt"), "output_", "program_", 1),
+			}
+			if *explain {
+				cfg.ExplainPath = strings.Replace(strings.TrimSuffix(fileName, ".txt"), "output_", "explain_", 1)
+			}
+			original := runExecution(decodedInstructions, cfg, *dynamic && records == nil, encodedInstructions, opts)
+			if exec.forwarding && exec.dataHazardControl && exec.controlHazardControl && v == variants[0] {
+				inOrder = original
+			}
+			if *schedule {
+				if v.predictor != "" {
+					cfg.Predictor, _ = predictor.Parse(v.predictor)
+				}
+				comparisons = append(comparisons, runScheduled(decodedInstructions, cfg, original))
+			}
+			if *unifiedMemory {
+				if v.predictor != "" {
+					cfg.Predictor, _ = predictor.Parse(v.predictor)
+				}
+				portComparisons = append(portComparisons,
+					runUnified(decodedInstructions, cfg, original, *dynamic && records == nil, encodedInstructions, opts))
+			}
+		}
+	}
+	if *schedule {
+		printComparisons(comparisons)
+	}
+	if *unifiedMemory {
+		printPortComparisons(portComparisons)
+	}
+	if *scoreboardModel {
+		cfg := scoreboard.DefaultConfig()
+		if err := parseScoreboardUnits(*scoreboardUnits, &cfg); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		runScoreboard(decodedInstructions, cfg, inOrder)
+	}
+	if *outOfOrder {
+		cfg := tomasulo.DefaultConfig()
+		cfg.ROBSize = *robSize
+		cfg.IssueWidth = max(*issueWidth, 1)
+		cfg.CommitWidth = cfg.IssueWidth
+		if err := parseStations(*stations, &cfg); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		runOutOfOrder(decodedInstructions, cfg, inOrder)
+	}
+}
+
+// parseCache reads a cache configuration; an empty spec means no cache.
+func parseCache(spec string) (*cache.Config, error) {
+	if spec == "" {
+		return nil, nil
+	}
+	cfg, err := cache.Parse(spec)
+	if err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// scheduleDelaySlots rewrites the program for a delayed-branch pipeline,
+// reporting how the slots were filled.
+func scheduleDelaySlots(program []isa.Instruction, slots int) []isa.Instruction {
+	scheduled, stats, err := delayslot.Schedule(program, slots)
+	if err != nil {
+		fmt.Printf("Delay slots: programa não relocado: %v\n", err)
+		os.Exit(1)
+	}
+
+	file, err := os.Create(DELAY_SLOT_FILE_NAME)
+	if err != nil {
+		fmt.Printf("Error to create file %s: %v\n", DELAY_SLOT_FILE_NAME, err)
+	} else {
+		defer file.Close()
+		if err := delayslot.WriteListing(file, scheduled, stats); err != nil {
+			fmt.Printf("Error to write in file %s: %v\n", DELAY_SLOT_FILE_NAME, err)
+		}
+	}
+
+	fmt.Printf("\nDelay slots: %d por desvio (%d desvios)\n", slots, stats.Branches)
+	fmt.Printf("Slots preenchidos: %d de %d (antes %d, alvo %d, sequência %d)\n",
+		stats.Filled(), stats.Slots, stats.FromBefore, stats.FromTarget, stats.FromFallThrough)
+	fmt.Printf("Slots desperdiçados (NOP): %d\n", stats.Wasted)
+	fmt.Printf("Programa reescalonado: %s\n", DELAY_SLOT_FILE_NAME)
+	return delayslot.Instructions(scheduled)
+}
+
+// runExecution runs one pipeline configuration. A dynamic run gets its own
+// machine, with the program's console output discarded.
+func runExecution(decoded []isa.Instruction, cfg runner.Config, dynamic bool, program []isa.RawInstruction, opts machineOptions) *runner.Pipeline {
+	if dynamic {
+		var err error
+		cfg.Machine, err = newMachine(program, opts)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		kernel := cfg.Machine.State.Env.(*pk.Kernel)
+		kernel.Stdin = strings.NewReader("")
+		kernel.Stdout = io.Discard
+		kernel.Stderr = io.Discard
 	}
+	return runner.Run(decoded, cfg)
 }
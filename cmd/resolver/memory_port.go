@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"riscv-instruction-encoder/pkg/isa"
+	"riscv-instruction-encoder/pkg/runner"
+	"strings"
+)
+
+// portComparison pairs the run of a configuration with split instruction
+// and data memories with its run on a unified memory with a single port.
+type portComparison struct {
+	name      string
+	interlock bool
+	split     *runner.Pipeline
+	unified   *runner.Pipeline
+}
+
+// runUnified runs the configuration described by cfg again with a unified
+// memory, writing its outputs next to the split-memory ones.
+func runUnified(decoded []isa.Instruction, cfg runner.Config, split *runner.Pipeline, dynamic bool, program []isa.RawInstruction, opts machineOptions) portComparison {
+	name := strings.TrimSuffix(cfg.FilePath, ".txt")
+	cfg.FilePath = name + "_unified.txt"
+	if cfg.DiagramPath != "" {
+		cfg.DiagramPath += "_unified"
+	}
+	// port conflicts are hardware stalls, so no NOP-padded program could
+	// run on this pipeline
+	cfg.ProgramPath = ""
+	if cfg.ExplainPath != "" {
+		cfg.ExplainPath += "_unified"
+	}
+	cfg.UnifiedMemory = true
+
+	return portComparison{
+		name:      strings.TrimPrefix(filepath.Base(name), "output_"),
+		interlock: cfg.Interlock,
+		split:     split,
+		unified:   runExecution(decoded, cfg, dynamic, program, opts),
+	}
+}
+
+func printPortComparisons(comparisons []portComparison) {
+	fmt.Println("\nComparação: memórias separadas x memória unificada")
+	fmt.Printf("%-40s %12s %12s %10s %8s %9s %8s\n", "Configuração", "NOPs/stalls", "unificada", "conflitos", "ciclos", "unificada", "custo")
+	for _, c := range comparisons {
+		cost := 0.0
+		if c.split.CurrentCycle > 0 {
+			cost = float64(c.unified.CurrentCycle-c.split.CurrentCycle) / float64(c.split.CurrentCycle) * 100
+		}
+		fmt.Printf("%-40s %12d %12d %10d %8d %9d %7.1f%%\n", c.name,
+			penalty(c.split, c.interlock), penalty(c.unified, c.interlock), c.unified.PortConflicts(),
+			c.split.CurrentCycle, c.unified.CurrentCycle, cost)
+	}
+	fmt.Println("========================================")
+}
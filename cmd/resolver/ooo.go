@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"riscv-instruction-encoder/pkg/isa"
+	"riscv-instruction-encoder/pkg/runner"
+	"riscv-instruction-encoder/pkg/tomasulo"
+	"strconv"
+	"strings"
+)
+
+const TOMASULO_FILE_NAME = "../../pkg/files/tomasulo.txt"
+
+// parseUnitCounts reads a count per functional unit, either one count for
+// every unit ("4") or per unit ("alu:3,lsu:2,bru:2"), and passes each to set.
+func parseUnitCounts(spec string, set func(unit isa.Unit, n int)) error {
+	if n, err := strconv.Atoi(spec); err == nil {
+		for unit := isa.Unit(0); unit < isa.NumUnits; unit++ {
+			set(unit, n)
+		}
+		return nil
+	}
+	for _, part := range strings.Split(spec, ",") {
+		name, count, ok := strings.Cut(part, ":")
+		n, err := strconv.Atoi(count)
+		if !ok || err != nil {
+			return fmt.Errorf("quantidade inválida: %s", part)
+		}
+		found := false
+		for unit := isa.Unit(0); unit < isa.NumUnits; unit++ {
+			if strings.EqualFold(unit.String(), name) {
+				set(unit, n)
+				found = true
+			}
+		}
+		if !found {
+			return fmt.Errorf("unidade desconhecida: %s", name)
+		}
+	}
+	return nil
+}
+
+// parseStations reads the reservation stations per unit.
+func parseStations(spec string, cfg *tomasulo.Config) error {
+	return parseUnitCounts(spec, func(unit isa.Unit, n int) { cfg.Units[unit].Stations = n })
+}
+
+// runOutOfOrder runs the Tomasulo model and compares its IPC with the
+// in-order pipeline run on the same program, when there is one.
+func runOutOfOrder(program []isa.Instruction, cfg tomasulo.Config, inOrder *runner.Pipeline) {
+	result, err := tomasulo.Run(program, cfg)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if err := result.WriteReport(TOMASULO_FILE_NAME); err != nil {
+		fmt.Printf("Error to write in file %s: %v\n", TOMASULO_FILE_NAME, err)
+	}
+
+	fmt.Println("\n-- FORA DE ORDEM (Tomasulo)")
+	fmt.Printf("Output: %s\n", TOMASULO_FILE_NAME)
+	fmt.Printf("ROB: %d entradas, emissão: %d por ciclo\n", cfg.ROBSize, cfg.IssueWidth)
+	fmt.Printf("Stalls de emissão: ROB cheio %d, estações cheias %d, desvio pendente %d\n",
+		result.Stalls.ROBFull, result.Stalls.StationsFull, result.Stalls.Branch)
+	fmt.Printf("Ciclos: %d\n", result.Cycles)
+	fmt.Printf("IPC: %.2f\n", result.IPC())
+	if inOrder != nil && inOrder.CurrentCycle > 0 {
+		count := len(inOrder.Instructions) - inOrder.NOPs()
+		ipc := float64(count) / float64(inOrder.CurrentCycle)
+		fmt.Printf("IPC em ordem (integrado com forwarding): %.2f (%d ciclos), ganho %.2fx\n",
+			ipc, inOrder.CurrentCycle, result.IPC()/ipc)
+	}
+	fmt.Println("========================================")
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"riscv-instruction-encoder/pkg/hazard"
+	"riscv-instruction-encoder/pkg/isa"
+	"riscv-instruction-encoder/pkg/runner"
+	"riscv-instruction-encoder/pkg/scheduler"
+	"strings"
+)
+
+// comparison pairs the run of a configuration on the program as written
+// with its run on the reordered program.
+type comparison struct {
+	name      string
+	interlock bool
+	original  *runner.Pipeline
+	scheduled *runner.Pipeline
+	stats     scheduler.Stats
+}
+
+// runScheduled reorders the program for the pipeline described by cfg and
+// runs it, writing its outputs next to the original ones.
+func runScheduled(decoded []isa.Instruction, cfg runner.Config, original *runner.Pipeline) comparison {
+	program, stats := scheduler.Schedule(decoded, hazard.Config{Forwarding: cfg.Forwarding, Paths: cfg.ForwardingPaths, ResolveStage: cfg.BranchResolveStage, Topology: cfg.Topology, Units: cfg.Units, StaticProgram: true})
+
+	name := strings.TrimSuffix(cfg.FilePath, ".txt")
+	cfg.FilePath = name + "_scheduled.txt"
+	if cfg.DiagramPath != "" {
+		cfg.DiagramPath += "_scheduled"
+	}
+	if cfg.ProgramPath != "" {
+		cfg.ProgramPath += "_scheduled"
+	}
+	if cfg.ExplainPath != "" {
+		cfg.ExplainPath += "_scheduled"
+	}
+	fmt.Printf("\nEscalonado: %d blocos, %d instruções movidas, ciclos ociosos previstos %d -> %d\n",
+		stats.Blocks, stats.Moved, stats.GapsBefore, stats.Gaps)
+
+	return comparison{
+		name:      strings.TrimPrefix(filepath.Base(name), "output_"),
+		interlock: cfg.Interlock,
+		original:  original,
+		scheduled: runner.Run(program, cfg),
+		stats:     stats,
+	}
+}
+
+// penalty is the cost the hazards added to a run: inserted NOPs, or stall
+// cycles under interlocks. Memory port conflicts stall in either mode.
+func penalty(p *runner.Pipeline, interlock bool) int {
+	if interlock {
+		return p.StallCycles
+	}
+	return p.NOPs() + p.StallCycles
+}
+
+// overhead is the penalty in percent of the program instructions.
+func overhead(p *runner.Pipeline, interlock bool) float64 {
+	count := len(p.Instructions) - p.NOPs()
+	return float64(penalty(p, interlock)) / float64(count) * 100
+}
+
+func printComparisons(comparisons []comparison) {
+	fmt.Println("\nComparação: programa original x escalonado")
+	fmt.Printf("%-40s %12s %12s %12s %12s %8s %8s\n", "Configuração", "NOPs/stalls", "escalonado", "sobrecusto", "escalonado", "ciclos", "escal.")
+	for _, c := range comparisons {
+		fmt.Printf("%-40s %12d %12d %11.1f%% %11.1f%% %8d %8d\n", c.name,
+			penalty(c.original, c.interlock), penalty(c.scheduled, c.interlock),
+			overhead(c.original, c.interlock), overhead(c.scheduled, c.interlock),
+			c.original.CurrentCycle, c.scheduled.CurrentCycle)
+	}
+	fmt.Println("========================================")
+}
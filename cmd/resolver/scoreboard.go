@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"riscv-instruction-encoder/pkg/isa"
+	"riscv-instruction-encoder/pkg/runner"
+	"riscv-instruction-encoder/pkg/scoreboard"
+)
+
+const SCOREBOARD_FILE_NAME = "../../pkg/files/scoreboard.txt"
+
+// parseScoreboardUnits reads the functional units per kind of the
+// scoreboard model.
+func parseScoreboardUnits(spec string, cfg *scoreboard.Config) error {
+	return parseUnitCounts(spec, func(unit isa.Unit, n int) { cfg.Units[unit].Count = n })
+}
+
+// runScoreboard runs the scoreboard model and compares its IPC with the
+// in-order pipeline run on the same program, when there is one.
+func runScoreboard(program []isa.Instruction, cfg scoreboard.Config, inOrder *runner.Pipeline) {
+	result, err := scoreboard.Run(program, cfg)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if err := result.WriteReport(SCOREBOARD_FILE_NAME); err != nil {
+		fmt.Printf("Error to write in file %s: %v\n", SCOREBOARD_FILE_NAME, err)
+	}
+
+	fmt.Println("\n-- SCOREBOARD (CDC 6600)")
+	fmt.Printf("Output: %s\n", SCOREBOARD_FILE_NAME)
+	fmt.Printf("Stalls de emissão: estrutural %d, WAW %d, desvio pendente %d\n",
+		result.Stalls.Structural, result.Stalls.WAW, result.Stalls.Branch)
+	fmt.Printf("Ciclos de escrita adiada por WAR: %d\n", result.Stalls.WAR)
+	fmt.Printf("Ciclos: %d\n", result.Cycles)
+	fmt.Printf("IPC: %.2f\n", result.IPC())
+	if inOrder != nil && inOrder.CurrentCycle > 0 {
+		count := len(inOrder.Instructions) - inOrder.NOPs()
+		ipc := float64(count) / float64(inOrder.CurrentCycle)
+		fmt.Printf("IPC em ordem (integrado com forwarding): %.2f (%d ciclos), ganho %.2fx\n",
+			ipc, inOrder.CurrentCycle, result.IPC()/ipc)
+	}
+	fmt.Println("========================================")
+}
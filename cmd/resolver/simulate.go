@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"riscv-instruction-encoder/pkg/cosim"
+	"riscv-instruction-encoder/pkg/isa"
+	"riscv-instruction-encoder/pkg/memory"
+	"riscv-instruction-encoder/pkg/pk"
+	"riscv-instruction-encoder/pkg/sim"
+	"riscv-instruction-encoder/pkg/symbols"
+	"strconv"
+	"strings"
+)
+
+// machineOptions configures the simulator memory shared by every machine
+// the resolver creates.
+type machineOptions struct {
+	misaligned memory.MisalignedPolicy
+	dataImage  string
+}
+
+func newMachine(program []isa.RawInstruction, opts machineOptions) (*sim.Machine, error) {
+	machine := sim.NewMachine()
+	machine.Memory.Misaligned = opts.misaligned
+	if opts.dataImage != "" {
+		if err := machine.Memory.LoadHexImage(opts.dataImage); err != nil {
+			return nil, err
+		}
+	}
+	if err := machine.LoadProgram(program, 0); err != nil {
+		return nil, err
+	}
+	attachKernel(machine, "")
+	return machine, nil
+}
+
+// attachKernel services the program's ECALLs with the proxy kernel. When
+// the ELF defines tohost, the riscv-tests host interface is polled too.
+func attachKernel(machine *sim.Machine, elfPath string) *pk.Kernel {
+	kernel := pk.New(machine.HeapStart)
+	if elfPath != "" {
+		if table, err := symbols.LoadELF(elfPath); err == nil {
+			kernel.ToHost, kernel.HasToHost = table.Find("tohost")
+		}
+	}
+	machine.State.Env = kernel
+	return kernel
+}
+
+// parseDump reads a region written as "address:length", both in hex.
+func parseDump(region string) (uint32, int, error) {
+	addrText, lengthText, ok := strings.Cut(region, ":")
+	if !ok {
+		return 0, 0, fmt.Errorf("região inválida: %s (use endereço:tamanho)", region)
+	}
+	addr, err := strconv.ParseUint(strings.TrimPrefix(addrText, "0x"), 16, 32)
+	if err != nil {
+		return 0, 0, fmt.Errorf("endereço inválido: %s", addrText)
+	}
+	length, err := strconv.ParseUint(strings.TrimPrefix(lengthText, "0x"), 16, 32)
+	if err != nil {
+		return 0, 0, fmt.Errorf("tamanho inválido: %s", lengthText)
+	}
+	return uint32(addr), int(length), nil
+}
+
+// runCosim compares the machine with a Spike commit log and returns the
+// process exit code: 0 when the executions agree, 1 otherwise.
+func runCosim(machine *sim.Machine, tracePath string, maxSteps int) int {
+	file, err := os.Open(tracePath)
+	if err != nil {
+		fmt.Printf("erro ao abrir trace: %v\n", err)
+		return 1
+	}
+	defer file.Close()
+
+	result, err := cosim.Run(machine, cosim.NewReader(file), maxSteps)
+	if err != nil {
+		fmt.Printf("Co-simulação interrompida: %v\n", err)
+		return 1
+	}
+	fmt.Printf("\nCo-simulação: %d instruções comparadas (%d ignoradas antes da sincronização)\n",
+		result.Compared, result.Skipped)
+	if result.Syscalls > 0 {
+		fmt.Printf("Chamadas de sistema: %d (%d instruções da referência no tratador ignoradas)\n",
+			result.Syscalls, result.Handled)
+	}
+	if result.Divergence != nil {
+		fmt.Print(result.Divergence.String())
+		return 1
+	}
+	fmt.Println("Nenhuma divergência encontrada")
+	return 0
+}
+
+// runSimulation runs the machine to completion, prints its final state and
+// returns the process exit code: the program's own status when it exits
+// through the kernel, 1 when it traps.
+func runSimulation(machine *sim.Machine, maxSteps int, dump string) int {
+	err := machine.Run(maxSteps)
+	fmt.Println()
+	machine.DumpRegisters(os.Stdout)
+	stats := machine.Memory.Stats
+	fmt.Printf("Memória: %d leituras, %d escritas, %d desalinhadas (%d divididas), %d páginas\n",
+		stats.Loads, stats.Stores, stats.Misaligned, stats.Split, machine.Memory.Pages())
+	if dump != "" {
+		addr, length, dumpErr := parseDump(dump)
+		if dumpErr != nil {
+			fmt.Println(dumpErr)
+		} else {
+			machine.Memory.HexDump(os.Stdout, addr, length)
+		}
+	}
+
+	if err != nil {
+		fmt.Printf("Simulação interrompida: %v\n", err)
+		return 1
+	}
+	kernel, _ := machine.State.Env.(*pk.Kernel)
+	if kernel == nil || !kernel.Exited {
+		return 0
+	}
+	fmt.Printf("Resultado: %s\n", kernel.Result())
+	if kernel.Passed() {
+		return 0
+	}
+	if kernel.ExitCode > 0 && kernel.ExitCode < 256 {
+		return kernel.ExitCode
+	}
+	return 1
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"riscv-instruction-encoder/pkg/hazard"
+	"riscv-instruction-encoder/pkg/isa"
+	"riscv-instruction-encoder/pkg/symbols"
+	"riscv-instruction-encoder/pkg/verifier"
+)
+
+// describe names the instruction at index i by mnemonic and address, with
+// its symbol when there is one, e.g. ADD@0x18 <main+0x18>.
+func describe(program []isa.Instruction, i int, syms *symbols.Table) string {
+	pc := uint32(i * 4)
+	text := fmt.Sprintf("%s@0x%X", program[i].GetMeta().Name, pc)
+	if name := syms.Format(pc); name != "" {
+		text += " <" + name + ">"
+	}
+	return text
+}
+
+// runVerify lints the program for the configuration and returns the exit
+// code: 0 when it is hazard-free, 1 otherwise.
+func runVerify(program []isa.Instruction, cfg hazard.Config, syms *symbols.Table) int {
+	resolve := cfg.ResolveStage
+	if resolve == 0 {
+		resolve = cfg.Pipeline().Resolve
+	}
+	forwardingText := "sem forwarding"
+	if cfg.Forwarding {
+		forwardingText = "com forwarding"
+	}
+	fmt.Printf("\nVerificação (%s, resolução de desvios em %s)\n", forwardingText, cfg.Pipeline().StageName(resolve))
+
+	findings := verifier.Verify(program, cfg)
+	for _, f := range findings {
+		producer := describe(program, f.Producer, syms)
+		consumer := describe(program, f.Consumer, syms)
+		switch f.Kind {
+		case verifier.RAW:
+			fmt.Printf("RAW em x%d (%s): %s -> %s, faltam %d slots\n", f.Register, isa.RegisterNames[f.Register], producer, consumer, f.Missing)
+		case verifier.WAW:
+			fmt.Printf("WAW em x%d (%s): %s -> %s, faltam %d slots\n", f.Register, isa.RegisterNames[f.Register], producer, consumer, f.Missing)
+		case verifier.Control:
+			fmt.Printf("Controle: %s -> %s, faltam %d slots\n", producer, consumer, f.Missing)
+		}
+	}
+	if len(findings) == 0 {
+		fmt.Println("Nenhum hazard encontrado.")
+		return 0
+	}
+	fmt.Printf("%d hazards encontrados.\n", len(findings))
+	return 1
+}
@@ -0,0 +1,300 @@
+// Package cache models a set-associative cache in front of a memory with a
+// fixed miss penalty. It tracks tags only, never data: the functional
+// simulator keeps the values, the cache only decides how long an access
+// takes.
+package cache
+
+import (
+	"fmt"
+	"math/bits"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+// Policy chooses the line of a full set that is replaced on a miss.
+type Policy int
+
+const (
+	LRU Policy = iota
+	FIFO
+	Random
+)
+
+func (p Policy) String() string {
+	switch p {
+	case FIFO:
+		return "FIFO"
+	case Random:
+		return "aleatória"
+	}
+	return "LRU"
+}
+
+type Config struct {
+	// Size and LineSize are in bytes; both are powers of two.
+	Size     int
+	LineSize int
+	// Ways is the associativity; Size/LineSize ways is fully associative.
+	Ways   int
+	Policy Policy
+	// WriteBack writes a modified line to memory only when it is evicted;
+	// otherwise every store is written through to memory.
+	WriteBack bool
+	// WriteAllocate brings the line into the cache on a store miss;
+	// otherwise the store goes straight to memory.
+	WriteAllocate bool
+	// MissPenalty is the number of cycles it takes to move a line between
+	// the cache and memory.
+	MissPenalty int
+}
+
+// DefaultConfig is a 1 KiB two-way cache with 16-byte lines.
+func DefaultConfig() Config {
+	return Config{
+		Size:          1024,
+		LineSize:      16,
+		Ways:          2,
+		Policy:        LRU,
+		WriteBack:     true,
+		WriteAllocate: true,
+		MissPenalty:   10,
+	}
+}
+
+// Parse changes the default configuration with a comma-separated list of
+// key=value settings: size, line, ways, policy (lru, fifo or random),
+// write (back or through), allocate (yes or no) and penalty, e.g.
+// "size=4096,ways=4,policy=fifo". "default" keeps the defaults.
+func Parse(spec string) (Config, error) {
+	cfg := DefaultConfig()
+	if spec == "default" {
+		return cfg, nil
+	}
+	for _, part := range strings.Split(spec, ",") {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return cfg, fmt.Errorf("configuração de cache inválida: %s", part)
+		}
+		var err error
+		switch strings.ToLower(key) {
+		case "size":
+			cfg.Size, err = strconv.Atoi(value)
+		case "line":
+			cfg.LineSize, err = strconv.Atoi(value)
+		case "ways":
+			cfg.Ways, err = strconv.Atoi(value)
+		case "penalty":
+			cfg.MissPenalty, err = strconv.Atoi(value)
+		case "policy":
+			switch strings.ToLower(value) {
+			case "lru":
+				cfg.Policy = LRU
+			case "fifo":
+				cfg.Policy = FIFO
+			case "random":
+				cfg.Policy = Random
+			default:
+				err = fmt.Errorf("política desconhecida")
+			}
+		case "write":
+			switch strings.ToLower(value) {
+			case "back":
+				cfg.WriteBack = true
+			case "through":
+				cfg.WriteBack = false
+			default:
+				err = fmt.Errorf("esperado back ou through")
+			}
+		case "allocate":
+			switch strings.ToLower(value) {
+			case "yes":
+				cfg.WriteAllocate = true
+			case "no":
+				cfg.WriteAllocate = false
+			default:
+				err = fmt.Errorf("esperado yes ou no")
+			}
+		default:
+			err = fmt.Errorf("chave desconhecida")
+		}
+		if err != nil {
+			return cfg, fmt.Errorf("configuração de cache inválida: %s", part)
+		}
+	}
+	return cfg, cfg.validate()
+}
+
+func (c Config) validate() error {
+	if c.Size <= 0 || c.LineSize <= 0 || c.Ways <= 0 || c.MissPenalty < 0 {
+		return fmt.Errorf("cache: tamanho, linha e vias devem ser positivos")
+	}
+	if bits.OnesCount(uint(c.Size)) != 1 || bits.OnesCount(uint(c.LineSize)) != 1 {
+		return fmt.Errorf("cache: tamanho e linha devem ser potências de dois")
+	}
+	lines := c.Size / c.LineSize
+	if lines == 0 || lines%c.Ways != 0 || bits.OnesCount(uint(lines/c.Ways)) != 1 {
+		return fmt.Errorf("cache: %d linhas não se dividem em conjuntos de %d vias", lines, c.Ways)
+	}
+	return nil
+}
+
+func (c Config) String() string {
+	write := "write-through"
+	if c.WriteBack {
+		write = "write-back"
+	}
+	allocate := "no-write-allocate"
+	if c.WriteAllocate {
+		allocate = "write-allocate"
+	}
+	return fmt.Sprintf("%d B, linhas de %d B, %d vias, %s, %s, %s, penalidade %d", c.Size, c.LineSize, c.Ways,
+		c.Policy, write, allocate, c.MissPenalty)
+}
+
+// Stats counts the accesses and the traffic to memory they caused.
+type Stats struct {
+	Reads  int
+	Writes int
+	Hits   int
+	Misses int
+	// Evictions counts valid lines replaced to make room for another.
+	Evictions int
+	// WriteBacks counts modified lines written to memory on eviction.
+	WriteBacks int
+	// WritesThrough counts stores sent to memory without allocating or
+	// under write-through.
+	WritesThrough int
+}
+
+func (s Stats) Accesses() int {
+	return s.Reads + s.Writes
+}
+
+// HitRate is the percentage of accesses that hit.
+func (s Stats) HitRate() float64 {
+	if s.Accesses() == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Accesses()) * 100
+}
+
+type line struct {
+	valid  bool
+	dirty  bool
+	tag    uint32
+	used   int
+	filled int
+}
+
+type Cache struct {
+	cfg    Config
+	sets   [][]line
+	offset int
+	index  int
+	clock  int
+	random *rand.Rand
+	stats  Stats
+}
+
+func New(cfg Config) (*Cache, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	sets := cfg.Size / cfg.LineSize / cfg.Ways
+	c := &Cache{
+		cfg:    cfg,
+		sets:   make([][]line, sets),
+		offset: bits.TrailingZeros(uint(cfg.LineSize)),
+		index:  bits.TrailingZeros(uint(sets)),
+		// a fixed seed keeps runs reproducible
+		random: rand.New(rand.NewSource(1)),
+	}
+	for i := range c.sets {
+		c.sets[i] = make([]line, cfg.Ways)
+	}
+	return c, nil
+}
+
+func (c *Cache) Config() Config {
+	return c.cfg
+}
+
+func (c *Cache) Stats() Stats {
+	return c.stats
+}
+
+// Access looks up addr for a read or a write and returns the cycles the
+// access stalls: a line fill on a miss, plus writing back the modified line
+// it replaces. Writes to memory that do not fill a line are assumed to be
+// absorbed by a write buffer.
+func (c *Cache) Access(addr uint32, write bool) int {
+	c.clock++
+	if write {
+		c.stats.Writes++
+	} else {
+		c.stats.Reads++
+	}
+
+	set := c.sets[(addr>>c.offset)&(uint32(len(c.sets))-1)]
+	tag := addr >> (c.offset + c.index)
+	for i := range set {
+		if set[i].valid && set[i].tag == tag {
+			c.stats.Hits++
+			set[i].used = c.clock
+			if write {
+				c.write(&set[i])
+			}
+			return 0
+		}
+	}
+
+	c.stats.Misses++
+	if write && !c.cfg.WriteAllocate {
+		c.stats.WritesThrough++
+		return 0
+	}
+	victim := &set[c.victim(set)]
+	stall := c.cfg.MissPenalty
+	if victim.valid {
+		c.stats.Evictions++
+		if victim.dirty {
+			c.stats.WriteBacks++
+			stall += c.cfg.MissPenalty
+		}
+	}
+	*victim = line{valid: true, tag: tag, used: c.clock, filled: c.clock}
+	if write {
+		c.write(victim)
+	}
+	return stall
+}
+
+func (c *Cache) write(l *line) {
+	if c.cfg.WriteBack {
+		l.dirty = true
+	} else {
+		c.stats.WritesThrough++
+	}
+}
+
+// victim picks an invalid line if the set has one, otherwise the line the
+// replacement policy chooses.
+func (c *Cache) victim(set []line) int {
+	for i := range set {
+		if !set[i].valid {
+			return i
+		}
+	}
+	if c.cfg.Policy == Random {
+		return c.random.Intn(len(set))
+	}
+	victim := 0
+	for i := range set {
+		if c.cfg.Policy == LRU && set[i].used < set[victim].used ||
+			c.cfg.Policy == FIFO && set[i].filled < set[victim].filled {
+			victim = i
+		}
+	}
+	return victim
+}
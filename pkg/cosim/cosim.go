@@ -0,0 +1,245 @@
+// Package cosim checks the functional simulator in lock-step against a
+// reference commit log, stopping at the first retired instruction whose PC,
+// encoding, register writes or memory accesses differ.
+package cosim
+
+import (
+	"fmt"
+	"io"
+	"riscv-instruction-encoder/pkg/isa"
+	"riscv-instruction-encoder/pkg/sim"
+	"strings"
+)
+
+// recorder sits between the instructions and the machine memory to capture
+// the data accesses of one step.
+type recorder struct {
+	inner    isa.Memory
+	accesses []MemAccess
+}
+
+func (r *recorder) Load(addr uint32, size int) (uint32, error) {
+	value, err := r.inner.Load(addr, size)
+	if err == nil {
+		r.accesses = append(r.accesses, MemAccess{Addr: addr, Value: value})
+	}
+	return value, err
+}
+
+func (r *recorder) Store(addr uint32, size int, value uint32) error {
+	err := r.inner.Store(addr, size, value)
+	if err == nil {
+		r.accesses = append(r.accesses, MemAccess{Addr: addr, Value: value, Store: true})
+	}
+	return err
+}
+
+// Fetch and SetByte let the environment read and write memory without
+// being recorded.
+func (r *recorder) Fetch(addr uint32) (uint32, error) {
+	if m, ok := r.inner.(interface{ Fetch(uint32) (uint32, error) }); ok {
+		return m.Fetch(addr)
+	}
+	return r.inner.Load(addr, 4)
+}
+
+func (r *recorder) SetByte(addr uint32, value byte) {
+	if m, ok := r.inner.(interface{ SetByte(uint32, byte) }); ok {
+		m.SetByte(addr, value)
+		return
+	}
+	r.inner.Store(addr, 1, uint32(value))
+}
+
+// Divergence describes the first mismatch between the two executions.
+type Divergence struct {
+	Index     int // retired instruction number, from 1
+	Expected  *Commit
+	PC        uint32
+	Insn      uint32
+	Decoded   string
+	Mismatch  []string
+	SimTrap   error
+	TraceDone bool
+}
+
+func (d *Divergence) String() string {
+	var b strings.Builder
+	if d.TraceDone {
+		fmt.Fprintf(&b, "Trace de referência terminou antes do simulador (instrução #%d em 0x%08X)\n", d.Index, d.PC)
+		return b.String()
+	}
+
+	fmt.Fprintf(&b, "Divergência na instrução #%d (linha %d do trace)\n", d.Index, d.Expected.Line)
+	fmt.Fprintf(&b, "  %-12s %-24s %s\n", "", "referência", "simulador")
+	fmt.Fprintf(&b, "  %-12s 0x%08X%-14s 0x%08X\n", "PC", d.Expected.PC, "", d.PC)
+	fmt.Fprintf(&b, "  %-12s 0x%08X%-14s 0x%08X %s\n", "instrução", d.Expected.Insn, "", d.Insn, d.Decoded)
+	for _, m := range d.Mismatch {
+		fmt.Fprintf(&b, "  %s\n", m)
+	}
+	if d.SimTrap != nil {
+		fmt.Fprintf(&b, "  trap no simulador: %v\n", d.SimTrap)
+	}
+	return b.String()
+}
+
+type Result struct {
+	Compared int
+	// Skipped counts the commits before the machine's starting PC.
+	Skipped int
+	// Syscalls counts the ECALLs serviced by the machine's environment,
+	// and Handled the commits of the reference while it serviced them.
+	Syscalls   int
+	Handled    int
+	Divergence *Divergence
+}
+
+// Run compares the machine against the trace for at most maxSteps
+// instructions. Commits before the machine's starting PC (Spike's boot ROM)
+// are skipped so the two executions can synchronise; a trace that never
+// reaches that PC is an error, and one that ends while the machine is still
+// running is a divergence.
+//
+// An ECALL serviced by the machine's environment is not compared: the
+// reference traps into its own handler or proxy kernel instead, so its
+// commits are skipped until it returns to the PC after the ECALL, or to the
+// end of the trace once the program has exited.
+func Run(machine *sim.Machine, trace *Reader, maxSteps int) (*Result, error) {
+	result := &Result{}
+	state := machine.State
+	rec := &recorder{inner: state.Memory}
+	state.Memory = rec
+	defer func() { state.Memory = rec.inner }()
+
+	synced, handling := false, false
+	for maxSteps <= 0 || result.Compared < maxSteps {
+		if synced && !state.Halted && isSyscall(machine) {
+			if _, err := machine.Step(); err != nil {
+				return result, err
+			}
+			result.Syscalls++
+			synced, handling = false, true
+			continue
+		}
+
+		expected, err := trace.Next()
+		if err == io.EOF {
+			if result.Compared == 0 {
+				return result, fmt.Errorf("nenhuma das %d instruções do trace está no PC inicial 0x%08X do simulador",
+					result.Skipped, state.PC)
+			}
+			if !state.Halted {
+				result.Divergence = &Divergence{Index: result.Compared + 1, PC: state.PC, TraceDone: true}
+			}
+			return result, nil
+		}
+		if err != nil {
+			return result, err
+		}
+		if !synced {
+			if state.Halted || expected.PC != state.PC {
+				if handling {
+					result.Handled++
+				} else {
+					result.Skipped++
+				}
+				continue
+			}
+			synced, handling = true, false
+		}
+		if state.Halted {
+			result.Divergence = &Divergence{Index: result.Compared + 1, Expected: expected, PC: state.PC,
+				Mismatch: []string{"simulador já terminou a execução"}}
+			return result, nil
+		}
+
+		result.Compared++
+		if d := step(machine, rec, expected); d != nil {
+			d.Index = result.Compared
+			result.Divergence = d
+			return result, nil
+		}
+	}
+	return result, nil
+}
+
+// isSyscall reports whether the next instruction is an ECALL the machine's
+// environment services.
+func isSyscall(machine *sim.Machine) bool {
+	if machine.State.Env == nil {
+		return false
+	}
+	inst, err := machine.Fetch()
+	return err == nil && inst.GetMeta().Name == "ECALL"
+}
+
+func step(machine *sim.Machine, rec *recorder, expected *Commit) *Divergence {
+	state := machine.State
+	d := &Divergence{Expected: expected, PC: state.PC}
+	d.Insn, _ = machine.Memory.Fetch(state.PC)
+
+	before := state.Regs
+	rec.accesses = rec.accesses[:0]
+	inst, err := machine.Step()
+	if inst != nil {
+		d.Decoded = inst.String()
+	}
+	d.SimTrap = err
+
+	if d.PC != expected.PC {
+		d.Mismatch = append(d.Mismatch, "PC diferente")
+	}
+	if d.Insn != expected.Insn {
+		d.Mismatch = append(d.Mismatch, "codificação diferente")
+	}
+	if len(d.Mismatch) > 0 || err != nil {
+		return d
+	}
+
+	reported := make(map[uint8]bool)
+	for _, w := range expected.RegWrites {
+		if w.Reg == 0 {
+			continue
+		}
+		reported[w.Reg] = true
+		if got := state.Regs[w.Reg]; got != w.Value {
+			d.Mismatch = append(d.Mismatch, fmt.Sprintf("%-12s 0x%08X%-14s 0x%08X",
+				regName(w.Reg), w.Value, "", got))
+		}
+	}
+	for r := uint8(1); r < 32; r++ {
+		if !reported[r] && state.Regs[r] != before[r] {
+			d.Mismatch = append(d.Mismatch, fmt.Sprintf("%-12s %-24s 0x%08X",
+				regName(r), "(não escrito)", state.Regs[r]))
+		}
+	}
+
+	if len(expected.Mem) != len(rec.accesses) {
+		d.Mismatch = append(d.Mismatch, fmt.Sprintf("%-12s %-24d %d",
+			"acessos mem", len(expected.Mem), len(rec.accesses)))
+	} else {
+		for i, want := range expected.Mem {
+			got := rec.accesses[i]
+			if want.Addr != got.Addr || want.Store != got.Store || (want.Store && want.Value != got.Value) {
+				d.Mismatch = append(d.Mismatch, fmt.Sprintf("%-12s %-24s %s",
+					"mem", describeAccess(want), describeAccess(got)))
+			}
+		}
+	}
+
+	if len(d.Mismatch) > 0 {
+		return d
+	}
+	return nil
+}
+
+func regName(r uint8) string {
+	return fmt.Sprintf("x%d (%s)", r, isa.RegisterNames[r])
+}
+
+func describeAccess(a MemAccess) string {
+	if a.Store {
+		return fmt.Sprintf("st 0x%08X=0x%X", a.Addr, a.Value)
+	}
+	return fmt.Sprintf("ld 0x%08X", a.Addr)
+}
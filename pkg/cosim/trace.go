@@ -0,0 +1,144 @@
+package cosim
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+type RegWrite struct {
+	Reg   uint8
+	Value uint32
+}
+
+type MemAccess struct {
+	Addr  uint32
+	Value uint32
+	Store bool
+}
+
+// Commit is one retired instruction of a Spike --log-commits trace:
+//
+//	core   0: 3 0x80000010 (0x0002a283) x5  0x00000000 mem 0x80001000
+//	core   0: 3 0x80000014 (0x00532023) mem 0x80001000 0x00000005
+type Commit struct {
+	Line      int
+	PC        uint32
+	Insn      uint32
+	RegWrites []RegWrite
+	Mem       []MemAccess
+}
+
+// Reader parses a commit log one retired instruction at a time, skipping
+// the lines Spike prints that are not commits (e.g. the instruction
+// disassembly of --log).
+type Reader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func NewReader(r io.Reader) *Reader {
+	return &Reader{scanner: bufio.NewScanner(r)}
+}
+
+// Next returns the next commit, or io.EOF at the end of the trace.
+func (r *Reader) Next() (*Commit, error) {
+	for r.scanner.Scan() {
+		r.line++
+		commit, ok, err := ParseLine(r.scanner.Text())
+		if err != nil {
+			return nil, fmt.Errorf("linha %d: %w", r.line, err)
+		}
+		if ok {
+			commit.Line = r.line
+			return commit, nil
+		}
+	}
+	if err := r.scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+func parseHex(text string) (uint32, error) {
+	v, err := strconv.ParseUint(strings.TrimPrefix(text, "0x"), 16, 64)
+	if err != nil {
+		return 0, fmt.Errorf("valor hexadecimal inválido %q", text)
+	}
+	// RV64 traces print 64-bit values; the simulator is RV32
+	return uint32(v), nil
+}
+
+// ParseLine parses a single commit line. ok is false for lines that are not
+// commits.
+func ParseLine(line string) (commit *Commit, ok bool, err error) {
+	_, rest, found := strings.Cut(line, ":")
+	if !found || !strings.HasPrefix(strings.TrimSpace(line), "core") {
+		return nil, false, nil
+	}
+	fields := strings.Fields(rest)
+	// privilege, pc and (insn) are mandatory in a commit line
+	if len(fields) < 3 || !strings.HasPrefix(fields[2], "(") {
+		return nil, false, nil
+	}
+
+	commit = &Commit{}
+	if commit.PC, err = parseHex(fields[1]); err != nil {
+		return nil, false, err
+	}
+	if commit.Insn, err = parseHex(strings.Trim(fields[2], "()")); err != nil {
+		return nil, false, err
+	}
+
+	for i := 3; i < len(fields); i++ {
+		field := fields[i]
+		switch {
+		case field == "mem":
+			if i+1 >= len(fields) {
+				return nil, false, fmt.Errorf("acesso à memória sem endereço")
+			}
+			access := MemAccess{}
+			if access.Addr, err = parseHex(fields[i+1]); err != nil {
+				return nil, false, err
+			}
+			i++
+			// a second value after the address is the data of a store
+			if i+1 < len(fields) && strings.HasPrefix(fields[i+1], "0x") {
+				if access.Value, err = parseHex(fields[i+1]); err != nil {
+					return nil, false, err
+				}
+				access.Store = true
+				i++
+			}
+			commit.Mem = append(commit.Mem, access)
+		case len(field) > 1 && field[0] == 'x' && isDigits(field[1:]):
+			reg, _ := strconv.Atoi(field[1:])
+			if reg > 31 || i+1 >= len(fields) {
+				return nil, false, fmt.Errorf("escrita de registrador inválida %q", field)
+			}
+			value, err := parseHex(fields[i+1])
+			if err != nil {
+				return nil, false, err
+			}
+			commit.RegWrites = append(commit.RegWrites, RegWrite{Reg: uint8(reg), Value: value})
+			i++
+		default:
+			// CSR and floating-point writes are not modelled: skip the value
+			if i+1 < len(fields) && strings.HasPrefix(fields[i+1], "0x") {
+				i++
+			}
+		}
+	}
+	return commit, true, nil
+}
+
+func isDigits(text string) bool {
+	for _, c := range text {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return text != ""
+}
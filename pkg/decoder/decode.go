@@ -12,6 +12,7 @@ import (
 	"riscv-instruction-encoder/pkg/isa/rtype"
 	"riscv-instruction-encoder/pkg/isa/stype"
 	"riscv-instruction-encoder/pkg/isa/utype"
+	"riscv-instruction-encoder/pkg/symbols"
 	"strconv"
 )
 
@@ -21,6 +22,7 @@ const (
 	OpIType2 = 0x03
 	OpIType3 = 0x67
 	OpIType4 = 0x73
+	OpIType5 = 0x0F
 	OpSType  = 0x23
 	OpBType  = 0x63
 	OpUType1 = 0x37
@@ -39,7 +41,7 @@ func DecodeInstruction(inst uint32) isa.Instruction {
 	switch op {
 	case OpRType:
 		return new(rtype.Type).Decode(inst)
-	case OpIType1, OpIType2, OpIType3, OpIType4:
+	case OpIType1, OpIType2, OpIType3, OpIType4, OpIType5:
 		return new(itype.Type).Decode(inst)
 	case OpSType:
 		return new(stype.Type).Decode(inst)
@@ -54,13 +56,20 @@ func DecodeInstruction(inst uint32) isa.Instruction {
 	}
 }
 
-func DecodeInstructionFromUInt32(encodedInstructions []isa.RawInstruction) []isa.Instruction {
+// DecodeInstructionFromUInt32 decodes and lists the program, printing the
+// labels of syms before the instructions they name. syms may be nil.
+func DecodeInstructionFromUInt32(encodedInstructions []isa.RawInstruction, syms *symbols.Table) []isa.Instruction {
 	var instructions = make([]isa.Instruction, len(encodedInstructions))
 	for i, inst := range encodedInstructions {
+		pc := uint32(i * 4)
+		for _, label := range syms.Labels(pc) {
+			fmt.Printf("%s:\n", label)
+		}
+
 		decoded := DecodeInstruction(inst.Value)
 		if decoded != nil {
 			instructions[i] = decoded
-			fmt.Printf("instrução %s -> %s\n", inst.Origin, decoded.String())
+			fmt.Printf("instrução %s -> %s%s\n", inst.Origin, decoded.String(), syms.Annotate(pc, decoded))
 		} else {
 			fmt.Printf("Opcode %02X não reconhecido\n", inst.Value&0x7F)
 		}
@@ -0,0 +1,302 @@
+// Package delayslot rewrites a program for a delayed-branch architecture, in
+// which the N instructions after every branch or jump always execute.
+package delayslot
+
+import (
+	"fmt"
+	"riscv-instruction-encoder/pkg/isa"
+)
+
+type SlotKind int
+
+const (
+	// Program is an instruction that keeps its place.
+	Program SlotKind = iota
+	// FromBefore is an independent instruction moved down from the branch's
+	// own basic block; it was going to execute anyway.
+	FromBefore
+	// FromTarget copies an instruction of the taken path; the branch is
+	// retargeted to continue after the copied instructions.
+	FromTarget
+	// FromFallThrough keeps the instruction after the branch in the slot,
+	// which is harmless when the taken path does not need its result.
+	FromFallThrough
+	// Wasted is a NOP put in a slot nothing could fill.
+	Wasted
+)
+
+func (k SlotKind) String() string {
+	switch k {
+	case FromBefore:
+		return "antes"
+	case FromTarget:
+		return "alvo"
+	case FromFallThrough:
+		return "sequência"
+	case Wasted:
+		return "NOP"
+	}
+	return ""
+}
+
+type Slot struct {
+	Instruction isa.Instruction
+	// OriginalPC is the address in the input program, -1 for added NOPs.
+	OriginalPC int
+	Kind       SlotKind
+	// DelayOf is the original PC of the branch this slot belongs to.
+	DelayOf int
+}
+
+type Stats struct {
+	Branches        int
+	Slots           int
+	FromBefore      int
+	FromTarget      int
+	FromFallThrough int
+	Wasted          int
+}
+
+func (s Stats) Filled() int {
+	return s.FromBefore + s.FromTarget + s.FromFallThrough
+}
+
+// Schedule gives every branch and jump of the program slots delay slots,
+// filling them first with instructions from before the branch, then from
+// the target or fall-through path when that is safe, and with NOPs
+// otherwise. Every branch and jump is then re-encoded for the new layout.
+// Addresses are assumed to start at 0 in steps of 4.
+func Schedule(program []isa.Instruction, slots int) ([]Slot, Stats, error) {
+	var stats Stats
+	leader := isa.Leaders(program)
+	entry := entries(program, slots, leader)
+	// consumed instructions already serve as fall-through slots
+	consumed := make([]bool, len(program))
+
+	var out []Slot
+	blockStart := 0
+	for b, inst := range program {
+		if leader[b] {
+			blockStart = len(out)
+		}
+		if consumed[b] {
+			continue
+		}
+		meta := inst.GetMeta()
+		out = append(out, Slot{Instruction: inst, OriginalPC: b * 4, Kind: Program})
+		if !meta.IsControl() || slots == 0 {
+			continue
+		}
+
+		stats.Branches++
+		stats.Slots += slots
+		branchPos := len(out) - 1
+		var filled []Slot
+
+		// 1. independent instructions of the same basic block
+		for len(filled) < slots {
+			pos := findBefore(out, blockStart, branchPos, entry)
+			if pos < 0 {
+				break
+			}
+			slot := out[pos]
+			slot.Kind = FromBefore
+			filled = append(filled, slot)
+			out = append(out[:pos], out[pos+1:]...)
+			branchPos--
+		}
+
+		// 2. the taken path for jumps, or whichever path is safe for branches
+		if len(filled) < slots {
+			filled = append(filled, fromPaths(program, b, slots-len(filled), leader, entry, consumed)...)
+		}
+
+		for _, slot := range filled {
+			slot.DelayOf = b * 4
+			switch slot.Kind {
+			case FromBefore:
+				stats.FromBefore++
+			case FromTarget:
+				stats.FromTarget++
+			case FromFallThrough:
+				stats.FromFallThrough++
+			}
+			out = append(out, slot)
+		}
+		for len(filled) < slots {
+			filled = append(filled, Slot{})
+			out = append(out, Slot{Instruction: isa.NewNOP(), OriginalPC: -1, Kind: Wasted, DelayOf: b * 4})
+			stats.Wasted++
+		}
+		blockStart = len(out)
+	}
+	return out, stats, relocate(out, len(program))
+}
+
+// entries marks where execution can enter the program other than by
+// falling through: the leaders, and every place a branch whose taken path
+// is copied into its slots may continue at.
+func entries(program []isa.Instruction, slots int, leader []bool) []bool {
+	entry := append([]bool(nil), leader...)
+	for b, inst := range program {
+		target, ok := inst.(isa.BranchTarget)
+		if !ok || !inst.GetMeta().IsControl() {
+			continue
+		}
+		t := int(target.Target(uint32(b*4))) / 4
+		for i := 1; i <= slots && t+i >= 0 && t+i < len(program); i++ {
+			entry[t+i] = true
+		}
+	}
+	return entry
+}
+
+// findBefore looks backwards in out[start:branch] for an instruction that
+// can move past everything after it, including the branch. An instruction
+// with an entry point after it stays, or entering there would run it again
+// in the slot.
+func findBefore(out []Slot, start, branch int, entry []bool) int {
+	for pos := branch - 1; pos >= start; pos-- {
+		meta := out[pos].Instruction.GetMeta()
+		if out[pos].Kind != Program || isPinned(meta) || meta.Name == "NOP" {
+			continue
+		}
+		if enteredAfter(out[pos].OriginalPC/4, out[branch].OriginalPC/4, entry) {
+			continue
+		}
+		ok := true
+		for after := pos + 1; after <= branch && ok; after++ {
+			ok = isa.Independent(meta, out[after].Instruction.GetMeta())
+		}
+		if ok {
+			return pos
+		}
+	}
+	return -1
+}
+
+// isPinned matches the instructions that never go into a slot: branches
+// and jumps, the system instructions, whose effects go beyond their
+// registers, and AUIPC, whose result depends on its own address.
+func isPinned(meta isa.InstructionMeta) bool {
+	return meta.IsControl() || meta.IsSystem || meta.Name == "AUIPC"
+}
+
+func enteredAfter(i, branch int, entry []bool) bool {
+	for j := i + 1; j <= branch; j++ {
+		if entry[j] {
+			return true
+		}
+	}
+	return false
+}
+
+// fromPaths fills up to n slots of the control instruction at index b from
+// the taken path (copies) or the fall-through path (kept in place). An
+// entry point never moves into a slot.
+func fromPaths(program []isa.Instruction, b, n int, leader, entry, consumed []bool) []Slot {
+	inst := program[b]
+	meta := inst.GetMeta()
+	target, hasTarget := inst.(isa.BranchTarget)
+	conditional := meta.IsBranch && !meta.IsJump
+	var filled []Slot
+
+	if hasTarget {
+		t := int(target.Target(uint32(b*4))) / 4
+		fallThrough := b + 1
+		for i := 0; i < n && t+i >= 0 && t+i < len(program); i++ {
+			cand := program[t+i].GetMeta()
+			if isPinned(cand) || cand.IsStore || cand.IsLoad || (i > 0 && leader[t+i]) {
+				break
+			}
+			// a conditional branch also runs the slot when not taken
+			if rd, ok := cand.Dest(); conditional && ok && !deadAt(program, fallThrough, rd) {
+				break
+			}
+			filled = append(filled, Slot{Instruction: program[t+i], OriginalPC: (t + i) * 4, Kind: FromTarget})
+		}
+		if len(filled) > 0 || !conditional {
+			return filled
+		}
+
+		for i := 0; i < n; i++ {
+			f := b + 1 + i
+			if f >= len(program) || entry[f] {
+				break
+			}
+			cand := program[f].GetMeta()
+			if isPinned(cand) || cand.IsStore || cand.IsLoad {
+				break
+			}
+			if rd, ok := cand.Dest(); ok && !deadAt(program, t, rd) {
+				break
+			}
+			consumed[f] = true
+			filled = append(filled, Slot{Instruction: program[f], OriginalPC: f * 4, Kind: FromFallThrough})
+		}
+	}
+	return filled
+}
+
+// relocate re-encodes every branch and jump at its new address. A target
+// moves to the first instruction of the program that kept its place at or
+// after it, since the instructions moved from there into slots still run
+// before the block's branch completes; a branch whose taken path was
+// copied into its slots skips the copies. An address computed by AUIPC
+// would need the instructions that use it patched too, so a program with
+// AUIPC is refused.
+func relocate(out []Slot, n int) error {
+	for _, slot := range out {
+		if slot.Instruction.GetMeta().Name == "AUIPC" {
+			return fmt.Errorf("AUIPC em 0x%X calcula um endereço relativo ao PC que não é relocado", slot.OriginalPC)
+		}
+	}
+
+	// at[i] is the new index of original instruction i; n is the end
+	at := make([]int, n+1)
+	for i := range at {
+		at[i] = -1
+	}
+	at[n] = len(out)
+	copies := make(map[int]int)
+	for pos, slot := range out {
+		switch slot.Kind {
+		case Program:
+			at[slot.OriginalPC/4] = pos
+		case FromTarget:
+			copies[slot.DelayOf]++
+		}
+	}
+	for i := n - 1; i >= 0; i-- {
+		if at[i] < 0 {
+			at[i] = at[i+1]
+		}
+	}
+
+	for pos, slot := range out {
+		rel, ok := slot.Instruction.(isa.Relocatable)
+		if !ok || slot.OriginalPC < 0 {
+			continue
+		}
+		target := rel.Target(uint32(slot.OriginalPC))
+		if t := int(target) / 4; target%4 == 0 && t >= 0 && t <= n {
+			// outside the program, the absolute address stays
+			target = uint32(at[min(t+copies[slot.OriginalPC], n)] * 4)
+		}
+		inst, err := rel.Retarget(uint32(pos*4), target)
+		if err != nil {
+			return err
+		}
+		out[pos].Instruction = inst
+	}
+	return nil
+}
+
+// Instructions returns the rewritten program.
+func Instructions(slots []Slot) []isa.Instruction {
+	program := make([]isa.Instruction, len(slots))
+	for i, slot := range slots {
+		program[i] = slot.Instruction
+	}
+	return program
+}
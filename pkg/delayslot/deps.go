@@ -0,0 +1,22 @@
+package delayslot
+
+import "riscv-instruction-encoder/pkg/isa"
+
+// deadAt reports whether reg is certainly overwritten before being read on
+// the straight-line path starting at index start. Leaving the path through
+// a control instruction counts as live, to stay conservative.
+func deadAt(program []isa.Instruction, start int, reg int) bool {
+	for i := start; i < len(program); i++ {
+		meta := program[i].GetMeta()
+		if meta.Reads(reg) {
+			return false
+		}
+		if rd, ok := meta.Dest(); ok && rd == reg {
+			return true
+		}
+		if meta.IsControl() {
+			return false
+		}
+	}
+	return true
+}
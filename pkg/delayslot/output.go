@@ -0,0 +1,30 @@
+package delayslot
+
+import (
+	"fmt"
+	"io"
+)
+
+// WriteListing prints the rewritten program, marking where every delay
+// slot came from.
+func WriteListing(w io.Writer, slots []Slot, stats Stats) error {
+	if _, err := fmt.Fprintf(w, "PC\tOrigem\t\tInstruction\n===============================\n"); err != nil {
+		return err
+	}
+	for i, slot := range slots {
+		origin := "-"
+		if slot.OriginalPC >= 0 {
+			origin = fmt.Sprintf("0x%08X", slot.OriginalPC)
+		}
+		note := ""
+		if slot.Kind != Program {
+			note = fmt.Sprintf("  # slot de 0x%08X (%s)", slot.DelayOf, slot.Kind)
+		}
+		if _, err := fmt.Fprintf(w, "0x%08X\t%s\t%s%s\n", i*4, origin, slot.Instruction.String(), note); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "\nDesvios: %d, slots: %d, preenchidos: %d (antes %d, alvo %d, sequência %d), desperdiçados: %d\n",
+		stats.Branches, stats.Slots, stats.Filled(), stats.FromBefore, stats.FromTarget, stats.FromFallThrough, stats.Wasted)
+	return err
+}
@@ -0,0 +1,68 @@
+package hazard
+
+import "riscv-instruction-encoder/pkg/isa"
+
+// Config describes the pipeline features the detectors have to account for.
+type Config struct {
+	Forwarding bool
+	// Paths, when set with Forwarding, lists the forwarding paths the
+	// pipeline has; nil forwards every result from its class's produce
+	// point, as if all the needed paths existed.
+	Paths *Paths
+	// ResolveStage is the stage at whose end a branch or jump knows its
+	// outcome and target; the correct path is fetched the following cycle.
+	// Zero means the default of the topology.
+	ResolveStage isa.Stage
+	// Topology is the pipeline the instructions flow through; nil means
+	// isa.FiveStage.
+	Topology *isa.Topology
+	// Units, when set, gives instructions the latency of their functional
+	// unit; nil means every unit takes one cycle.
+	Units *Units
+	// UnifiedMemory makes instruction fetch and data accesses share a
+	// single memory port.
+	UnifiedMemory bool
+	// StaticProgram says the instructions come from the program listing in
+	// address order, where a NOP is padding rather than a path that was
+	// taken.
+	StaticProgram bool
+}
+
+// Pipeline returns the topology, defaulting to the five-stage pipeline.
+func (c Config) Pipeline() *isa.Topology {
+	if c.Topology == nil {
+		return isa.FiveStage
+	}
+	return c.Topology
+}
+
+func (c Config) resolveStage() isa.Stage {
+	if c.ResolveStage == 0 {
+		return c.Pipeline().Resolve
+	}
+	return c.ResolveStage
+}
+
+// consumeStage is the stage at which instruction needs its operands. A
+// branch resolved before EX compares its operands earlier than an ALU
+// instruction would use them, so with forwarding the value must arrive that
+// many cycles sooner. Without forwarding the operands are read from the
+// register file in ID either way.
+func (c Config) consumeStage(meta isa.InstructionMeta) isa.Stage {
+	stage := c.Pipeline().Consume(meta)
+	if execute := c.Pipeline().Execute; c.Forwarding && meta.IsBranch && c.resolveStage() < execute {
+		stage -= execute - c.resolveStage()
+	}
+	return stage
+}
+
+// produceStage is where a result can first be read by a consumer: the
+// forwarding point of the instruction's class, or the register file write
+// without forwarding, both later by the extra cycles of its unit.
+func (c Config) produceStage(meta isa.InstructionMeta) isa.Stage {
+	extra := isa.Stage(c.ExtraCycles(meta))
+	if !c.Forwarding {
+		return c.Pipeline().WriteBack + extra
+	}
+	return c.Pipeline().Produce(meta) + extra
+}
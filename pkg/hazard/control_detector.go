@@ -2,21 +2,54 @@ package hazard
 
 import "riscv-instruction-encoder/pkg/isa"
 
-func HasControlHazard(currentInstruction isa.PipelineInstruction, executing []*isa.PipelineInstruction, forwarding bool) bool {
-	for _, prev := range executing {
-		if hasUnresolvedBranchHazard(currentInstruction, *prev) {
-			return true
-		}
-	}
-
-	return false
+func HasControlHazard(currentInstruction isa.PipelineInstruction, executing []*isa.PipelineInstruction, cfg Config) bool {
+	_, ok := ExplainControlHazard(currentInstruction, executing, cfg)
+	return ok
 }
 
-func hasUnresolvedBranchHazard(currentInstruction isa.PipelineInstruction, previousInstruction isa.PipelineInstruction) bool {
+// hasUnresolvedBranchHazard reports whether currentInstruction would be
+// fetched past a branch or jump that has not resolved. In a static program
+// a NOP does nothing on either path, so it never waits: a program padded by
+// an earlier run keeps its padding instead of getting more. In an executed
+// stream the NOP may be the branch target and waits like any instruction.
+func hasUnresolvedBranchHazard(currentInstruction isa.PipelineInstruction, previousInstruction isa.PipelineInstruction, cfg Config) bool {
+	if cfg.StaticProgram && currentInstruction.Instruction != nil && isa.IsNOP(currentInstruction.Instruction) {
+		return false
+	}
 	prevMeta := previousInstruction.Instruction.GetMeta()
-	if (prevMeta.IsBranch || prevMeta.IsJump) && !previousInstruction.HasCompleted && previousInstruction.CurrentStage < int(isa.WB) {
+	if (prevMeta.IsBranch || prevMeta.IsJump) && !IsResolved(previousInstruction, cfg) {
 		return true
 	}
 
 	return false
 }
+
+// IsResolved reports whether a branch or jump has left the stage where its
+// outcome and target become known, which a slow branch unit delays when
+// the branch resolves after executing.
+func IsResolved(instruction isa.PipelineInstruction, cfg Config) bool {
+	resolve := int(cfg.resolveStage())
+	if resolve >= int(cfg.Pipeline().Execute) {
+		resolve += cfg.ExtraCycles(instruction.Instruction.GetMeta())
+	}
+	return instruction.HasCompleted || instruction.CurrentStage > resolve
+}
+
+// ControlDistance is the smallest number of cycles between issuing prev and
+// the next fetch for which HasControlHazard lets the fetch proceed; it is 1
+// for instructions that do not change the control flow.
+func ControlDistance(prev isa.Instruction, cfg Config) int {
+	stages := cfg.Pipeline().Len() + cfg.ExtraCycles(prev.GetMeta())
+	for distance := 1; distance < stages; distance++ {
+		branch := &isa.PipelineInstruction{
+			Instruction:  prev,
+			HasStarted:   true,
+			CurrentStage: int(isa.IF) + distance,
+		}
+		branch.HasCompleted = branch.CurrentStage >= stages
+		if !HasControlHazard(isa.PipelineInstruction{CurrentStage: int(isa.IF)}, []*isa.PipelineInstruction{branch}, cfg) {
+			return distance
+		}
+	}
+	return stages
+}
@@ -2,33 +2,29 @@ package hazard
 
 import "riscv-instruction-encoder/pkg/isa"
 
-func HasDataHazard(currentInstruction isa.PipelineInstruction, executing []*isa.PipelineInstruction, forwarding bool) bool {
-	for _, prev := range executing {
-		if isRAWHazard(currentInstruction, *prev, forwarding) || isWARHazard(*prev, currentInstruction, forwarding) {
-			return true
-		}
-	}
-	return false
+func HasDataHazard(currentInstruction isa.PipelineInstruction, executing []*isa.PipelineInstruction, cfg Config) bool {
+	_, ok := ExplainDataHazard(currentInstruction, executing, cfg)
+	return ok
 }
 
 // Read after Write Hazard detection
-func isRAWHazard(currentInstruction isa.PipelineInstruction, previousInstruction isa.PipelineInstruction, forwarding bool) bool {
+func isRAWHazard(currentInstruction isa.PipelineInstruction, previousInstruction isa.PipelineInstruction, cfg Config) bool {
+	if cfg.Forwarding && cfg.Paths != nil {
+		return isPathRAWHazard(currentInstruction, previousInstruction, cfg)
+	}
 	currMeta := currentInstruction.Instruction.GetMeta()
 	prevMeta := previousInstruction.Instruction.GetMeta()
 
-	if !prevMeta.WritesRegister || prevMeta.Rd == nil || !previousInstruction.HasStarted || previousInstruction.HasCompleted {
+	// x0 is never written, so nothing waits for it
+	rd, ok := prevMeta.Dest()
+	if !ok || !previousInstruction.HasStarted || previousInstruction.HasCompleted {
 		return false
 	}
 
 	for _, rs := range currMeta.Rs {
-		if rs == *prevMeta.Rd {
-			cyclesToConsume := int(currMeta.ConsumeStage) - currentInstruction.CurrentStage
-			var cyclesToProduce int
-			if !forwarding {
-				cyclesToProduce = int(isa.WB) - previousInstruction.CurrentStage
-			} else {
-				cyclesToProduce = int(prevMeta.ProduceStage) - previousInstruction.CurrentStage
-			}
+		if rs == rd {
+			cyclesToConsume := int(cfg.consumeStage(currMeta)) - currentInstruction.CurrentStage
+			cyclesToProduce := int(cfg.produceStage(prevMeta)) - previousInstruction.CurrentStage
 			if cyclesToProduce >= 0 && cyclesToConsume >= 0 && cyclesToProduce > cyclesToConsume {
 				return true
 			}
@@ -39,23 +35,19 @@ func isRAWHazard(currentInstruction isa.PipelineInstruction, previousInstruction
 }
 
 // Write after Read Hazard detection
-func isWARHazard(prevInstruction, currInstruction isa.PipelineInstruction, forwarding bool) bool {
+func isWARHazard(prevInstruction, currInstruction isa.PipelineInstruction, cfg Config) bool {
 	prevMeta := prevInstruction.Instruction.GetMeta()
 	currMeta := currInstruction.Instruction.GetMeta()
 
-	if !prevMeta.ReadsRegister || !currMeta.WritesRegister || currMeta.Rd == nil || !prevInstruction.HasStarted || prevInstruction.HasCompleted {
+	rd, ok := currMeta.Dest()
+	if !prevMeta.ReadsRegister || !ok || !prevInstruction.HasStarted || prevInstruction.HasCompleted {
 		return false
 	}
 
 	for _, rs := range prevMeta.Rs {
-		if rs == *currMeta.Rd {
-			var cyclesToWrite int
-			cyclesToRead := int(prevMeta.ConsumeStage) - prevInstruction.CurrentStage
-			if !forwarding {
-				cyclesToWrite = int(isa.WB) - currInstruction.CurrentStage
-			} else {
-				cyclesToWrite = int(currMeta.ProduceStage) - currInstruction.CurrentStage
-			}
+		if rs == rd {
+			cyclesToRead := int(cfg.consumeStage(prevMeta)) - prevInstruction.CurrentStage
+			cyclesToWrite := int(cfg.produceStage(currMeta)) - currInstruction.CurrentStage
 			if cyclesToWrite >= 0 && cyclesToRead >= 0 && cyclesToRead < cyclesToWrite {
 				return true
 			}
@@ -64,3 +56,44 @@ func isWARHazard(prevInstruction, currInstruction isa.PipelineInstruction, forwa
 
 	return false
 }
+
+// Write after Write Hazard detection. Writes reach the register file in
+// order unless the units have different latencies: then a short instruction
+// could write back before a longer one issued earlier to the same register,
+// whose stale result would overwrite it.
+func isWAWHazard(prevInstruction, currInstruction isa.PipelineInstruction, cfg Config) bool {
+	prevMeta := prevInstruction.Instruction.GetMeta()
+	currMeta := currInstruction.Instruction.GetMeta()
+
+	prevRd, prevOk := prevMeta.Dest()
+	currRd, currOk := currMeta.Dest()
+	if !prevOk || !currOk || prevRd != currRd || !prevInstruction.HasStarted || prevInstruction.HasCompleted {
+		return false
+	}
+
+	writeBack := cfg.Pipeline().WriteBack
+	cyclesToWritePrev := cfg.last(prevMeta, writeBack) - prevInstruction.CurrentStage
+	cyclesToWriteCurr := cfg.last(currMeta, writeBack) - currInstruction.CurrentStage
+	return cyclesToWriteCurr <= cyclesToWritePrev
+}
+
+// MinDistance is the smallest number of cycles between issuing prev and cur
+// for which HasDataHazard lets cur enter the pipeline. Consecutive
+// instructions are one cycle apart, so MinDistance-1 is the number of
+// slots that must separate them.
+func MinDistance(prev, cur isa.Instruction, cfg Config) int {
+	stages := cfg.Pipeline().Len() + cfg.ExtraCycles(prev.GetMeta())
+	for distance := 1; distance < stages; distance++ {
+		producer := &isa.PipelineInstruction{
+			Instruction:  prev,
+			HasStarted:   true,
+			CurrentStage: int(isa.IF) + distance,
+		}
+		producer.HasCompleted = producer.CurrentStage >= stages
+		consumer := isa.PipelineInstruction{Instruction: cur, CurrentStage: int(isa.IF)}
+		if !HasDataHazard(consumer, []*isa.PipelineInstruction{producer}, cfg) {
+			return distance
+		}
+	}
+	return stages
+}
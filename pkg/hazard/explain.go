@@ -0,0 +1,100 @@
+package hazard
+
+import "riscv-instruction-encoder/pkg/isa"
+
+// Kind classifies the hazard an instruction has to wait for.
+type Kind int
+
+const (
+	KindRAW Kind = iota
+	// KindLoadUse is a RAW on a load whose value arrives too late even
+	// with forwarding.
+	KindLoadUse
+	KindWAR
+	KindWAW
+	KindControl
+	KindStructural
+	KindMemoryPort
+	NumKinds
+)
+
+var kindNames = [NumKinds]string{"RAW", "load-use", "WAR", "WAW", "desvio não resolvido", "estrutural", "porta de memória"}
+
+func (k Kind) String() string {
+	return kindNames[k]
+}
+
+// Hazard explains why an instruction waits: the kind of hazard and the
+// instruction in flight it waits for, the register they share and the
+// functional unit that is busy, when those apply.
+type Hazard struct {
+	Kind Kind
+	// Producer is the earlier instruction: the writer of a RAW or a WAW,
+	// the reader of a WAR, the unresolved branch or the load or store on
+	// the memory port. It is nil for structural hazards.
+	Producer *isa.PipelineInstruction
+	// Register is -1 unless the hazard is on a register.
+	Register int
+	Unit     isa.Unit
+}
+
+// ExplainDataHazard returns the first data hazard currentInstruction has
+// with the instructions in flight.
+func ExplainDataHazard(currentInstruction isa.PipelineInstruction, executing []*isa.PipelineInstruction, cfg Config) (Hazard, bool) {
+	for _, prev := range executing {
+		if isRAWHazard(currentInstruction, *prev, cfg) {
+			prevMeta := prev.Instruction.GetMeta()
+			kind := KindRAW
+			if prevMeta.IsLoad && cfg.Forwarding {
+				kind = KindLoadUse
+			}
+			rd, _ := prevMeta.Dest()
+			return Hazard{Kind: kind, Producer: prev, Register: rd}, true
+		}
+		if isWARHazard(*prev, currentInstruction, cfg) {
+			rd, _ := currentInstruction.Instruction.GetMeta().Dest()
+			return Hazard{Kind: KindWAR, Producer: prev, Register: rd}, true
+		}
+		if isWAWHazard(*prev, currentInstruction, cfg) {
+			rd, _ := currentInstruction.Instruction.GetMeta().Dest()
+			return Hazard{Kind: KindWAW, Producer: prev, Register: rd}, true
+		}
+	}
+	return Hazard{}, false
+}
+
+// ExplainControlHazard returns the unresolved branch or jump that holds
+// currentInstruction back.
+func ExplainControlHazard(currentInstruction isa.PipelineInstruction, executing []*isa.PipelineInstruction, cfg Config) (Hazard, bool) {
+	for _, prev := range executing {
+		if hasUnresolvedBranchHazard(currentInstruction, *prev, cfg) {
+			return Hazard{Kind: KindControl, Producer: prev, Register: -1}, true
+		}
+	}
+	return Hazard{}, false
+}
+
+// ExplainStructuralHazard returns the unit next waits for when every unit
+// of its kind is busy.
+func (t *UnitTable) ExplainStructuralHazard(next isa.PipelineInstruction, cycle int, cfg Config) (Hazard, bool) {
+	if !t.HasStructuralHazard(next, cycle, cfg) {
+		return Hazard{}, false
+	}
+	return Hazard{Kind: KindStructural, Register: -1, Unit: isa.UnitOf(next.Instruction.GetMeta())}, true
+}
+
+// ExplainMemoryPortHazard returns the load or store that holds the unified
+// memory port in the cycle currentInstruction is to be fetched. A NOP needs
+// the port to be fetched like any other instruction.
+func ExplainMemoryPortHazard(currentInstruction isa.PipelineInstruction, executing []*isa.PipelineInstruction, cfg Config) (Hazard, bool) {
+	if !cfg.UnifiedMemory {
+		return Hazard{}, false
+	}
+	for _, prev := range executing {
+		meta := prev.Instruction.GetMeta()
+		if (meta.IsLoad || meta.IsStore) && !prev.HasCompleted && cfg.Stage(meta, prev.CurrentStage) == cfg.Pipeline().Memory {
+			return Hazard{Kind: KindMemoryPort, Producer: prev, Register: -1}, true
+		}
+	}
+	return Hazard{}, false
+}
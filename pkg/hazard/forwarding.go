@@ -0,0 +1,158 @@
+package hazard
+
+import (
+	"fmt"
+	"riscv-instruction-encoder/pkg/isa"
+	"strings"
+)
+
+// Path is a forwarding path (bypass) from a pipeline register to the input
+// of a stage. The names are those of the five-stage pipeline; in other
+// topologies the paths start after the execute and memory stages.
+type Path int
+
+const (
+	// PathEXMEMToEX feeds the execute stage from the register after it,
+	// serving the instruction right behind an ALU producer.
+	PathEXMEMToEX Path = iota
+	// PathMEMWBToEX feeds the execute stage from the register after the
+	// memory stage, two instructions behind or after a load.
+	PathMEMWBToEX
+	// PathMEMWBToMEM feeds the data of a store in the memory stage from the
+	// register after it, so a store can follow the load of its data.
+	PathMEMWBToMEM
+	NumPaths
+	// RegisterFile is the source of an operand read after its producer
+	// wrote it, when no path is needed.
+	RegisterFile = NumPaths
+)
+
+var pathNames = [...]string{"ex-ex", "mem-ex", "mem-mem"}
+
+// Paths tells which forwarding paths the pipeline has.
+type Paths [NumPaths]bool
+
+// AllPaths has every forwarding path.
+func AllPaths() Paths {
+	return Paths{true, true, true}
+}
+
+// ParsePaths reads a comma-separated list of paths among ex-ex (EX/MEM→EX),
+// mem-ex (MEM/WB→EX) and mem-mem (MEM/WB→MEM), or "all".
+func ParsePaths(spec string) (Paths, error) {
+	if spec == "all" {
+		return AllPaths(), nil
+	}
+	var paths Paths
+	for _, name := range strings.Split(spec, ",") {
+		found := false
+		for path, pathName := range pathNames {
+			if strings.EqualFold(name, pathName) {
+				paths[path] = true
+				found = true
+			}
+		}
+		if !found {
+			return paths, fmt.Errorf("caminho de forwarding desconhecido: %s (use ex-ex, mem-ex ou mem-mem)", name)
+		}
+	}
+	return paths, nil
+}
+
+// from is the stage whose pipeline register a path reads and to the stage
+// it feeds.
+func (c Config) pathStages(path Path) (from, to isa.Stage) {
+	t := c.Pipeline()
+	switch path {
+	case PathEXMEMToEX:
+		return t.Execute, t.Execute
+	case PathMEMWBToEX:
+		return t.Memory, t.Execute
+	}
+	return t.Memory, t.Memory
+}
+
+// PathName names a path after the pipeline registers of the topology, e.g.
+// EX/MEM→EX, or "banco de registradores".
+func (c Config) PathName(path Path) string {
+	if path == RegisterFile {
+		return "banco de registradores"
+	}
+	t := c.Pipeline()
+	from, to := c.pathStages(path)
+	return fmt.Sprintf("%s/%s→%s", t.StageName(from), t.StageName(from+1), t.StageName(to))
+}
+
+// first and last are the positions of the first and the last cycle an
+// instruction spends in stage; only the execute stage lasts more than one
+// cycle.
+func (c Config) first(meta isa.InstructionMeta, stage isa.Stage) int {
+	if stage <= c.Pipeline().Execute {
+		return int(stage)
+	}
+	return int(stage) + c.ExtraCycles(meta)
+}
+
+func (c Config) last(meta isa.InstructionMeta, stage isa.Stage) int {
+	if stage < c.Pipeline().Execute {
+		return int(stage)
+	}
+	return int(stage) + c.ExtraCycles(meta)
+}
+
+// OperandSource tells how operand (an index into Rs) of cur gets its value
+// from prev, an earlier instruction that writes it, at their current
+// distance: through the register file, written by prev before cur reads
+// it, or through an enabled forwarding path. ok is false when the value
+// cannot arrive in time.
+//
+// Operands are used at the start of the execute stage, except the data of a
+// store, which can also wait for the memory stage. Branches resolved before
+// the execute stage read their operands from the register file only.
+func OperandSource(cur, prev isa.PipelineInstruction, operand int, cfg Config) (Path, bool) {
+	curMeta := cur.Instruction.GetMeta()
+	prevMeta := prev.Instruction.GetMeta()
+	t := cfg.Pipeline()
+	// where prev is when cur reaches the first cycle of stage
+	producerAt := func(stage isa.Stage) int {
+		return prev.CurrentStage + cfg.first(curMeta, stage) - cur.CurrentStage
+	}
+
+	if producerAt(t.Consume(curMeta)) >= cfg.last(prevMeta, t.WriteBack) {
+		return RegisterFile, true
+	}
+	if !cfg.Forwarding || cfg.Paths == nil || (curMeta.IsBranch && cfg.resolveStage() < t.Execute) {
+		return 0, false
+	}
+
+	storeData := curMeta.IsStore && operand == 1
+	ready := cfg.last(prevMeta, t.Produce(prevMeta))
+	for path := Path(0); path < NumPaths; path++ {
+		from, to := cfg.pathStages(path)
+		if !cfg.Paths[path] || (path == PathMEMWBToMEM && !storeData) {
+			continue
+		}
+		if ready <= cfg.last(prevMeta, from) && producerAt(to) == cfg.first(prevMeta, from+1) {
+			return path, true
+		}
+	}
+	return 0, false
+}
+
+// isPathRAWHazard is the RAW check of a pipeline with individual forwarding
+// paths: every operand cur reads from prev needs a source in time.
+func isPathRAWHazard(currentInstruction isa.PipelineInstruction, previousInstruction isa.PipelineInstruction, cfg Config) bool {
+	rd, ok := previousInstruction.Instruction.GetMeta().Dest()
+	if !ok || !previousInstruction.HasStarted || previousInstruction.HasCompleted {
+		return false
+	}
+	for operand, rs := range currentInstruction.Instruction.GetMeta().Rs {
+		if rs != rd {
+			continue
+		}
+		if _, ok := OperandSource(currentInstruction, previousInstruction, operand, cfg); !ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,205 @@
+package hazard
+
+import (
+	"fmt"
+	"riscv-instruction-encoder/pkg/isa"
+	"strconv"
+	"strings"
+)
+
+// FunctionalUnit describes the units of one kind in the execute stage.
+type FunctionalUnit struct {
+	Count int
+	// Latency is the number of cycles an instruction spends executing; the
+	// result arrives Latency-1 cycles after the topology's produce point.
+	Latency int
+	// Pipelined units accept a new instruction every cycle, the others stay
+	// busy for the whole latency.
+	Pipelined bool
+}
+
+// Units configures every kind of functional unit.
+type Units [isa.NumUnits]FunctionalUnit
+
+// DefaultUnits has one unit of each kind, with a pipelined three-cycle
+// multiplier and an unpipelined ten-cycle divider.
+func DefaultUnits() Units {
+	return Units{
+		isa.UnitALU: {Count: 1, Latency: 1, Pipelined: true},
+		isa.UnitMUL: {Count: 1, Latency: 3, Pipelined: true},
+		isa.UnitDIV: {Count: 1, Latency: 10, Pipelined: false},
+		isa.UnitLSU: {Count: 1, Latency: 1, Pipelined: true},
+		isa.UnitBRU: {Count: 1, Latency: 1, Pipelined: true},
+	}
+}
+
+// ParseUnits changes the default units with a comma-separated list of
+// unit:count[:latency[:p|np]], e.g. "div:2:20:np,alu:2". "default" keeps
+// the defaults.
+func ParseUnits(spec string) (Units, error) {
+	units := DefaultUnits()
+	if spec == "default" {
+		return units, nil
+	}
+	for _, part := range strings.Split(spec, ",") {
+		fields := strings.Split(part, ":")
+		kind := isa.NumUnits
+		for u := isa.Unit(0); u < isa.NumUnits; u++ {
+			if strings.EqualFold(u.String(), fields[0]) {
+				kind = u
+			}
+		}
+		if kind == isa.NumUnits || len(fields) < 2 || len(fields) > 4 {
+			return units, fmt.Errorf("unidade inválida: %s", part)
+		}
+
+		u := &units[kind]
+		var err error
+		if u.Count, err = strconv.Atoi(fields[1]); err != nil || u.Count < 1 {
+			return units, fmt.Errorf("quantidade inválida: %s", part)
+		}
+		if len(fields) > 2 {
+			if u.Latency, err = strconv.Atoi(fields[2]); err != nil || u.Latency < 1 {
+				return units, fmt.Errorf("latência inválida: %s", part)
+			}
+		}
+		if len(fields) > 3 {
+			switch strings.ToLower(fields[3]) {
+			case "p":
+				u.Pipelined = true
+			case "np":
+				u.Pipelined = false
+			default:
+				return units, fmt.Errorf("esperado p ou np: %s", part)
+			}
+		}
+	}
+	return units, nil
+}
+
+func (u Units) String() string {
+	var parts []string
+	for kind, unit := range u {
+		text := fmt.Sprintf("%s %dx%d", isa.Unit(kind), unit.Count, unit.Latency)
+		if !unit.Pipelined {
+			text += " (não pipelined)"
+		}
+		parts = append(parts, text)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ExtraCycles is how many cycles longer than a single-cycle unit an
+// instruction stays in the execute stage.
+func (c Config) ExtraCycles(meta isa.InstructionMeta) int {
+	if c.Units == nil {
+		return 0
+	}
+	return max(c.Units[isa.UnitOf(meta)].Latency-1, 0)
+}
+
+// Depth is the most cycles an instruction can spend in the pipeline, so
+// no hazard reaches further back.
+func (c Config) Depth() int {
+	extra := 0
+	if c.Units != nil {
+		for _, u := range c.Units {
+			extra = max(extra, u.Latency-1)
+		}
+	}
+	return c.Pipeline().Len() + extra
+}
+
+// Stage maps the position of an instruction in the pipeline, which advances
+// every cycle, to the stage it occupies: the execute stage repeats for its
+// extra cycles.
+func (c Config) Stage(meta isa.InstructionMeta, position int) isa.Stage {
+	execute, extra := int(c.Pipeline().Execute), c.ExtraCycles(meta)
+	switch {
+	case position <= execute:
+		return isa.Stage(position)
+	case position <= execute+extra:
+		return isa.Stage(execute)
+	}
+	return isa.Stage(position - extra)
+}
+
+// UnitTable is the reservation table of the functional units: the first
+// cycle each unit can start an instruction.
+type UnitTable struct {
+	units Units
+	free  [isa.NumUnits][]int
+}
+
+func NewUnitTable(units Units) *UnitTable {
+	t := &UnitTable{units: units}
+	for kind, u := range units {
+		t.free[kind] = make([]int, u.Count)
+	}
+	return t
+}
+
+// executeCycle is the cycle instruction, fetched in cycle, reaches the
+// execute stage.
+func executeCycle(instruction isa.PipelineInstruction, cycle int, cfg Config) int {
+	return cycle + int(cfg.Pipeline().Execute) - instruction.CurrentStage
+}
+
+// find returns a unit of the kind next needs that is free when next reaches
+// the execute stage.
+func (t *UnitTable) find(next isa.PipelineInstruction, cycle int, cfg Config) (isa.Unit, int, bool) {
+	kind := isa.UnitOf(next.Instruction.GetMeta())
+	enter := executeCycle(next, cycle, cfg)
+	for i, free := range t.free[kind] {
+		if free <= enter {
+			return kind, i, true
+		}
+	}
+	return kind, 0, false
+}
+
+// HasStructuralHazard reports whether every unit next can execute on is
+// still busy in the cycle next would reach the execute stage. NOPs use no
+// unit.
+func (t *UnitTable) HasStructuralHazard(next isa.PipelineInstruction, cycle int, cfg Config) bool {
+	if isa.IsNOP(next.Instruction) {
+		return false
+	}
+	_, _, ok := t.find(next, cycle, cfg)
+	return !ok
+}
+
+// Reserve books the unit next executes on, after HasStructuralHazard let it
+// issue in cycle.
+func (t *UnitTable) Reserve(next isa.PipelineInstruction, cycle int, cfg Config) {
+	if isa.IsNOP(next.Instruction) {
+		return
+	}
+	kind, i, ok := t.find(next, cycle, cfg)
+	if !ok {
+		return
+	}
+	busy := 1
+	if !t.units[kind].Pipelined {
+		busy = t.units[kind].Latency
+	}
+	t.free[kind][i] = executeCycle(next, cycle, cfg) + busy
+}
+
+// Delay pushes every reservation back, for cycles in which the whole
+// pipeline is frozen.
+func (t *UnitTable) Delay(cycles int) {
+	for kind := range t.free {
+		for i := range t.free[kind] {
+			t.free[kind][i] += cycles
+		}
+	}
+}
+
+// HasMemoryPortHazard reports whether a load or store in flight accesses
+// data memory in the cycle currentInstruction is to be fetched, which a
+// unified memory with a single port cannot serve at the same time.
+func HasMemoryPortHazard(currentInstruction isa.PipelineInstruction, executing []*isa.PipelineInstruction, cfg Config) bool {
+	_, ok := ExplainMemoryPortHazard(currentInstruction, executing, cfg)
+	return ok
+}
@@ -26,3 +26,7 @@ func newBEQ(t Type) *BEQ {
 
 	return inst
 }
+
+func (i *BEQ) ExecuteOperation(s *isa.State) {
+	i.resolve(s, s.Latch.A == s.Latch.B)
+}
@@ -26,3 +26,7 @@ func newBLT(t Type) *BLT {
 
 	return inst
 }
+
+func (i *BLT) ExecuteOperation(s *isa.State) {
+	i.resolve(s, int32(s.Latch.A) < int32(s.Latch.B))
+}
@@ -0,0 +1,30 @@
+package btype
+
+import isa "riscv-instruction-encoder/pkg/isa"
+
+type BLTU struct {
+	Type
+}
+
+func newBLTU(t Type) *BLTU {
+	inst := &BLTU{Type: t}
+	inst.InstructionMeta = isa.InstructionMeta{
+		Name:           "BLTU",
+		OpCode:         uint32(t.OpCode),
+		IsLoad:         false,
+		IsStore:        false,
+		IsBranch:       true,
+		IsJump:         false,
+		WritesRegister: false,
+		ReadsRegister:  true,
+		Rs:             []int{int(t.Rs1), int(t.Rs2)},
+		Rd:             nil,
+		ProduceStage:   isa.EX,
+		ConsumeStage:   isa.ID,
+	}
+	return inst
+}
+
+func (i *BLTU) ExecuteOperation(s *isa.State) {
+	i.resolve(s, s.Latch.A < s.Latch.B)
+}
@@ -26,3 +26,7 @@ func newBNE(t Type) *BNE {
 
 	return inst
 }
+
+func (i *BNE) ExecuteOperation(s *isa.State) {
+	i.resolve(s, s.Latch.A != s.Latch.B)
+}
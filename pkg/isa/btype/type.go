@@ -52,33 +52,57 @@ func (b *Type) findInstruction() isa.Instruction {
 			return newBLT(*b)
 		case FUNCT3_BNE:
 			return newBNE(*b)
+		case FUNCT3_BGE:
+			return newBGE(*b)
+		case FUNCT3_BLTU:
+			return newBLTU(*b)
+		case FUNCT3_BGEU:
+			return newBGEU(*b)
 		}
 	}
 	return b
 }
 
-func (b *Type) String() string {
-	return fmt.Sprintf("%s {opcode=%02X, funct3=%d, rs1=%d, rs2=%d, imm=%d}",
-		b.InstructionMeta.Name, b.OpCode, b.Funct3, b.Rs1, b.Rs2, b.Imm)
+func (b *Type) Encode() uint32 {
+	imm := uint32(b.Imm)
+	return (imm>>12&0x1)<<31 | (imm>>5&0x3F)<<25 | uint32(b.Rs2)<<20 | uint32(b.Rs1)<<15 |
+		uint32(b.Funct3)<<12 | (imm>>1&0xF)<<8 | (imm>>11&0x1)<<7 | uint32(b.OpCode)
 }
 
-// Pipeline stages
-func (b *Type) ExecuteFetchInstruction() {
-	fmt.Printf("[IF ] Fetching instruction: %s\n", b.InstructionMeta.Name)
+// Retarget re-encodes the branch offset; branches reach ±4 KiB.
+func (b *Type) Retarget(pc, target uint32) (isa.Instruction, error) {
+	offset := int32(target - pc)
+	if offset < -4096 || offset > 4094 || offset&1 != 0 {
+		return nil, fmt.Errorf("desvio de 0x%08X para 0x%08X fora do alcance", pc, target)
+	}
+	moved := *b
+	moved.Imm = uint16(offset) & 0x1FFE
+	return moved.findInstruction(), nil
+}
+
+// Target returns the address reached when the branch is taken.
+func (b *Type) Target(pc uint32) uint32 {
+	return pc + uint32(isa.SignExtend(uint32(b.Imm), 13))
 }
 
-func (b *Type) ExecuteDecodeInstruction() {
-	fmt.Printf("[ID ] Decoding instruction: %s\n", b.InstructionMeta.Name)
+func (b *Type) String() string {
+	return fmt.Sprintf("%s {opcode=%02X, funct3=%d, rs1=%d, rs2=%d, imm=%d}",
+		b.InstructionMeta.Name, b.OpCode, b.Funct3, b.Rs1, b.Rs2, b.Imm)
 }
 
-func (b *Type) ExecuteOperation() {
-	fmt.Printf("[EX ] Executing operation for instruction: %s\n", b.InstructionMeta.Name)
+// Pipeline stages
+func (b *Type) ExecuteDecodeInstruction(s *isa.State) {
+	s.Latch.A = s.ReadReg(b.Rs1)
+	s.Latch.B = s.ReadReg(b.Rs2)
 }
 
-func (b *Type) ExecuteAccessOperand() {
-	fmt.Printf("[MEM] Accessing operands/memory for instruction: %s\n", b.InstructionMeta.Name)
+func (b *Type) ExecuteOperation(s *isa.State) {
+	s.IllegalInstruction(b.String())
 }
 
-func (b *Type) ExecuteWriteBack() {
-	fmt.Printf("[WB ] Writing back result of instruction: %s\n", b.InstructionMeta.Name)
+// resolve redirects the next PC to the branch target when taken.
+func (b *Type) resolve(s *isa.State, taken bool) {
+	if taken {
+		s.Latch.NextPC = b.Target(s.PC)
+	}
 }
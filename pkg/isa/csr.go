@@ -0,0 +1,86 @@
+package isa
+
+// Addresses of the control and status registers the simulator implements:
+// the user counters, the machine information registers, and the machine
+// trap and protection registers that riscv-tests programs set up before
+// their first test.
+const (
+	CSRSatp       = 0x180
+	CSRMstatus    = 0x300
+	CSRMisa       = 0x301
+	CSRMedeleg    = 0x302
+	CSRMideleg    = 0x303
+	CSRMie        = 0x304
+	CSRMtvec      = 0x305
+	CSRMcounteren = 0x306
+	CSRMscratch   = 0x340
+	CSRMepc       = 0x341
+	CSRMcause     = 0x342
+	CSRMtval      = 0x343
+	CSRMip        = 0x344
+	CSRPmpcfg0    = 0x3A0
+	CSRPmpaddr0   = 0x3B0
+	CSRCycle      = 0xC00
+	CSRTime       = 0xC01
+	CSRInstret    = 0xC02
+	CSRCycleh     = 0xC80
+	CSRTimeh      = 0xC81
+	CSRInstreth   = 0xC82
+	CSRMvendorid  = 0xF11
+	CSRMarchid    = 0xF12
+	CSRMimpid     = 0xF13
+	CSRMhartid    = 0xF14
+)
+
+// MisaRV32I is the misa value of the simulated hart: MXL = 32 bits and the
+// I base.
+const MisaRV32I = 1<<30 | 1<<('I'-'A')
+
+// storedCSR reports whether csr is a read-write register the simulator
+// keeps without giving its value any effect: privilege modes, traps and
+// memory protection are not modelled.
+func storedCSR(csr uint16) bool {
+	switch {
+	case csr == CSRSatp, csr >= CSRMstatus && csr <= CSRMcounteren && csr != CSRMisa,
+		csr >= CSRMscratch && csr <= CSRMip,
+		csr >= CSRPmpcfg0 && csr < CSRPmpcfg0+4, csr >= CSRPmpaddr0 && csr < CSRPmpaddr0+16:
+		return true
+	}
+	return false
+}
+
+// ReadCSR returns the value of csr, or false when the simulator does not
+// implement it. One instruction retires per cycle and time counts cycles,
+// so the three user counters read the same.
+func (s *State) ReadCSR(csr uint16) (uint32, bool) {
+	switch csr {
+	case CSRCycle, CSRTime, CSRInstret:
+		return uint32(s.Instret), true
+	case CSRCycleh, CSRTimeh, CSRInstreth:
+		return uint32(s.Instret >> 32), true
+	case CSRMisa:
+		return MisaRV32I, true
+	case CSRMvendorid, CSRMarchid, CSRMimpid, CSRMhartid:
+		return 0, true
+	}
+	if !storedCSR(csr) {
+		return 0, false
+	}
+	return s.csrs[csr], true
+}
+
+// WriteCSR sets csr, or returns false when it is read-only or not
+// implemented. Writes to misa are ignored, as its fields are WARL.
+func (s *State) WriteCSR(csr uint16, value uint32) bool {
+	if csr == CSRMisa {
+		return true
+	}
+	if !storedCSR(csr) {
+		return false
+	}
+	if s.csrs == nil {
+		s.csrs = make(map[uint16]uint32)
+	}
+	s.csrs[csr] = value
+	return true
+}
@@ -0,0 +1,70 @@
+package isa
+
+// Dest returns the register the instruction writes, ignoring x0.
+func (m InstructionMeta) Dest() (int, bool) {
+	if !m.WritesRegister || m.Rd == nil || *m.Rd == 0 {
+		return 0, false
+	}
+	return *m.Rd, true
+}
+
+// Reads reports whether the instruction reads reg, which is never x0.
+func (m InstructionMeta) Reads(reg int) bool {
+	if reg == 0 {
+		return false
+	}
+	for _, rs := range m.Rs {
+		if rs == reg {
+			return true
+		}
+	}
+	return false
+}
+
+func (m InstructionMeta) IsControl() bool {
+	return m.IsBranch || m.IsJump
+}
+
+// Independent reports whether first, originally executed before second, may
+// be executed after it: no register dependence (RAW, WAR or WAW) and no
+// memory access pair that involves a store.
+func Independent(first, second InstructionMeta) bool {
+	if rd, ok := first.Dest(); ok {
+		if second.Reads(rd) {
+			return false
+		}
+		if rd2, ok := second.Dest(); ok && rd2 == rd {
+			return false
+		}
+	}
+	if rd, ok := second.Dest(); ok && first.Reads(rd) {
+		return false
+	}
+	touchesMemory := func(m InstructionMeta) bool { return m.IsLoad || m.IsStore }
+	if touchesMemory(first) && touchesMemory(second) && (first.IsStore || second.IsStore) {
+		return false
+	}
+	return true
+}
+
+// Leaders marks the first instruction of every basic block of a program laid
+// out from address 0: the entry, every branch or jump target and every
+// instruction after a control instruction. The slice has one extra entry
+// for the end of the program.
+func Leaders(program []Instruction) []bool {
+	leader := make([]bool, len(program)+1)
+	leader[0] = true
+	for i, inst := range program {
+		if !inst.GetMeta().IsControl() {
+			continue
+		}
+		leader[i+1] = true
+		if target, ok := inst.(BranchTarget); ok {
+			t := int(target.Target(uint32(i*4))) / 4
+			if t >= 0 && t < len(program) {
+				leader[t] = true
+			}
+		}
+	}
+	return leader
+}
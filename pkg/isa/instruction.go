@@ -16,6 +16,22 @@ const (
 
 var Stages = []Stage{IF, ID, EX, MEM, WB}
 
+func (s Stage) String() string {
+	switch s {
+	case IF:
+		return "IF"
+	case ID:
+		return "ID"
+	case EX:
+		return "EX"
+	case MEM:
+		return "MEM"
+	case WB:
+		return "WB"
+	}
+	return fmt.Sprintf("S%d", int(s))
+}
+
 type RegisterUsage struct {
 	ReadRegs  []uint8
 	WriteRegs []uint8
@@ -34,21 +50,44 @@ type InstructionMeta struct {
 	Rs []int
 	Rd *int
 
+	// ProduceStage and ConsumeStage document the classic five-stage
+	// points; the hazard detectors take them from the Topology instead.
 	ProduceStage Stage
 	ConsumeStage Stage
+
+	// IsSystem marks the instructions whose effects go beyond their
+	// registers: ECALL, EBREAK, FENCE, MRET and the CSR instructions.
+	IsSystem bool
 }
 
 type Instruction interface {
 	String() string
 	Decode(inst uint32) Instruction
-	ExecuteFetchInstruction()
-	ExecuteDecodeInstruction()
-	ExecuteOperation()
-	ExecuteAccessOperand()
-	ExecuteWriteBack()
+	// Encode returns the 32-bit instruction word, the inverse of Decode.
+	Encode() uint32
+	ExecuteFetchInstruction(s *State)
+	ExecuteDecodeInstruction(s *State)
+	ExecuteOperation(s *State)
+	ExecuteAccessOperand(s *State)
+	ExecuteWriteBack(s *State)
 	GetMeta() InstructionMeta
 }
 
+// BranchTarget is implemented by branches and jumps whose destination can be
+// computed from the encoding alone, without reading any register.
+type BranchTarget interface {
+	Target(pc uint32) uint32
+}
+
+// Relocatable is a PC-relative branch or jump whose destination can be
+// changed when the code around it moves.
+type Relocatable interface {
+	BranchTarget
+	// Retarget returns a copy placed at pc that reaches target, or an error
+	// when the offset does not fit the immediate.
+	Retarget(pc, target uint32) (Instruction, error)
+}
+
 type PipelineInstruction struct {
 	Id           int
 	Instruction  Instruction
@@ -57,6 +96,13 @@ type PipelineInstruction struct {
 	HasStarted   bool
 	PC           int
 	OriginalPC   int
+	// NextPC is the address executed after this instruction, known only when
+	// the stream comes from an execution; otherwise it is OriginalPC + 4.
+	NextPC int
+	// Address is the effective address of a load or store, when HasAddress
+	// says it is known, which only an execution tells.
+	Address    uint32
+	HasAddress bool
 }
 
 type BaseInstruction struct {
@@ -71,15 +117,18 @@ func (b *BaseInstruction) SetMeta(i InstructionMeta) {
 	b.InstructionMeta = i
 }
 
-func (b *BaseInstruction) ExecuteFetchInstruction() {}
+// The default stages only advance the PC, which is all a NOP does.
+func (b *BaseInstruction) ExecuteFetchInstruction(s *State) {
+	s.Latch.NextPC = s.PC + 4
+}
 
-func (b *BaseInstruction) ExecuteDecodeInstruction() {}
+func (b *BaseInstruction) ExecuteDecodeInstruction(s *State) {}
 
-func (b *BaseInstruction) ExecuteOperation() {}
+func (b *BaseInstruction) ExecuteOperation(s *State) {}
 
-func (b *BaseInstruction) ExecuteAccessOperand() {}
+func (b *BaseInstruction) ExecuteAccessOperand(s *State) {}
 
-func (b *BaseInstruction) ExecuteWriteBack() {}
+func (b *BaseInstruction) ExecuteWriteBack(s *State) {}
 
 type RawInstruction struct {
 	Origin string
@@ -90,18 +139,24 @@ func IntPtr(v int) *int {
 	return &v
 }
 
-func ExecuteStage(stage Stage, instruction Instruction) {
+// SignExtend interprets the low bits of v as a two's complement number.
+func SignExtend(v uint32, bits uint) int32 {
+	shift := 32 - bits
+	return int32(v<<shift) >> shift
+}
+
+func ExecuteStage(stage Stage, instruction Instruction, s *State) {
 	switch stage {
 	case IF:
-		instruction.ExecuteFetchInstruction()
+		instruction.ExecuteFetchInstruction(s)
 	case ID:
-		instruction.ExecuteDecodeInstruction()
+		instruction.ExecuteDecodeInstruction(s)
 	case EX:
-		instruction.ExecuteOperation()
+		instruction.ExecuteOperation(s)
 	case MEM:
-		instruction.ExecuteAccessOperand()
+		instruction.ExecuteAccessOperand(s)
 	case WB:
-		instruction.ExecuteWriteBack()
+		instruction.ExecuteWriteBack(s)
 	default:
 		fmt.Printf("Stage not defined")
 	}
@@ -26,3 +26,7 @@ func newADDI(t Type) *ADDI {
 
 	return inst
 }
+
+func (i *ADDI) ExecuteOperation(s *isa.State) {
+	s.Latch.Result = s.Latch.A + s.Latch.B
+}
@@ -24,3 +24,7 @@ func NewANDI(t Type) *ANDI {
 	}
 	return inst
 }
+
+func (i *ANDI) ExecuteOperation(s *isa.State) {
+	s.Latch.Result = s.Latch.A & s.Latch.B
+}
@@ -0,0 +1,84 @@
+package itype
+
+import (
+	"fmt"
+	isa "riscv-instruction-encoder/pkg/isa"
+)
+
+// CSR is one of the six Zicsr instructions. funct3 picks the operation,
+// write, set bits or clear bits, and whether the operand is rs1 or the
+// zero-extended five-bit immediate in its place; the CSR address is the
+// I-type immediate.
+type CSR struct {
+	Type
+}
+
+var csrNames = map[uint8]string{
+	FUNCT3_CSRRW:  "CSRRW",
+	FUNCT3_CSRRS:  "CSRRS",
+	FUNCT3_CSRRC:  "CSRRC",
+	FUNCT3_CSRRWI: "CSRRWI",
+	FUNCT3_CSRRSI: "CSRRSI",
+	FUNCT3_CSRRCI: "CSRRCI",
+}
+
+func newCSR(t Type) *CSR {
+	inst := &CSR{Type: t}
+	var rs []int
+	if !inst.immediate() {
+		rs = []int{int(t.Rs1)}
+	}
+	inst.InstructionMeta = isa.InstructionMeta{
+		Name:           csrNames[t.Funct3],
+		OpCode:         uint32(t.OpCode),
+		IsLoad:         false,
+		IsStore:        false,
+		IsBranch:       false,
+		IsJump:         false,
+		IsSystem:       true,
+		WritesRegister: true,
+		ReadsRegister:  rs != nil,
+		Rs:             rs,
+		Rd:             isa.IntPtr(int(t.Rd)),
+		ProduceStage:   isa.EX,
+		ConsumeStage:   isa.ID,
+	}
+	return inst
+}
+
+// immediate reports whether the rs1 field holds the operand itself.
+func (i *CSR) immediate() bool {
+	return i.Funct3&0x4 != 0
+}
+
+func (i *CSR) ExecuteDecodeInstruction(s *isa.State) {
+	if i.immediate() {
+		s.Latch.A = uint32(i.Rs1)
+		return
+	}
+	s.Latch.A = s.ReadReg(i.Rs1)
+}
+
+// ExecuteOperation reads the old value into the result and writes the new
+// one. Setting or clearing bits with x0 or a zero immediate does not write,
+// so it is allowed on read-only registers.
+func (i *CSR) ExecuteOperation(s *isa.State) {
+	csr := i.Imm
+	old, ok := s.ReadCSR(csr)
+	if !ok {
+		s.Raise(fmt.Errorf("CSR 0x%03X não implementado em 0x%08X", csr, s.PC))
+		return
+	}
+	value, write := s.Latch.A, true
+	switch i.Funct3 &^ 0x4 {
+	case FUNCT3_CSRRS:
+		value, write = old|s.Latch.A, i.Rs1 != 0
+	case FUNCT3_CSRRC:
+		value, write = old&^s.Latch.A, i.Rs1 != 0
+	}
+	if write && !s.WriteCSR(csr, value) {
+		s.Raise(fmt.Errorf("CSR 0x%03X somente leitura em 0x%08X", csr, s.PC))
+		return
+	}
+	s.Latch.Result = old
+}
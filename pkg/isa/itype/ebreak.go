@@ -0,0 +1,39 @@
+package itype
+
+import isa "riscv-instruction-encoder/pkg/isa"
+
+type EBREAK struct {
+	Type
+}
+
+func newEBREAK(t Type) *EBREAK {
+	inst := &EBREAK{Type: t}
+	inst.InstructionMeta = isa.InstructionMeta{
+		Name:           "EBREAK",
+		OpCode:         uint32(t.OpCode),
+		IsLoad:         false,
+		IsStore:        false,
+		IsBranch:       false,
+		IsJump:         false,
+		IsSystem:       true,
+		WritesRegister: false,
+		ReadsRegister:  false,
+		Rs:             nil,
+		Rd:             nil,
+		ProduceStage:   0,
+		ConsumeStage:   isa.ID,
+	}
+	return inst
+}
+
+func (i *EBREAK) ExecuteDecodeInstruction(s *isa.State) {}
+
+func (i *EBREAK) ExecuteOperation(s *isa.State) {
+	if s.Env == nil {
+		s.IllegalInstruction(i.InstructionMeta.Name)
+		return
+	}
+	s.Env.Ebreak(s)
+}
+
+func (i *EBREAK) ExecuteWriteBack(s *isa.State) {}
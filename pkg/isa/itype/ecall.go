@@ -0,0 +1,42 @@
+package itype
+
+import isa "riscv-instruction-encoder/pkg/isa"
+
+type ECALL struct {
+	Type
+}
+
+// ECALL – chamada ao ambiente; o número do serviço vem em a7, os argumentos
+// em a0-a2 e o retorno volta em a0.
+func newECALL(t Type) *ECALL {
+	inst := &ECALL{Type: t}
+	inst.InstructionMeta = isa.InstructionMeta{
+		Name:           "ECALL",
+		OpCode:         uint32(t.OpCode),
+		IsLoad:         false,
+		IsStore:        false,
+		IsBranch:       false,
+		IsJump:         false,
+		IsSystem:       true,
+		WritesRegister: true,
+		ReadsRegister:  true,
+		Rs:             []int{17, 10, 11, 12},
+		Rd:             isa.IntPtr(10),
+		ProduceStage:   isa.EX,
+		ConsumeStage:   isa.ID,
+	}
+	return inst
+}
+
+func (i *ECALL) ExecuteDecodeInstruction(s *isa.State) {}
+
+func (i *ECALL) ExecuteOperation(s *isa.State) {
+	if s.Env == nil {
+		s.IllegalInstruction(i.InstructionMeta.Name)
+		return
+	}
+	s.Env.Ecall(s)
+}
+
+// a0 is written by the environment itself.
+func (i *ECALL) ExecuteWriteBack(s *isa.State) {}
@@ -0,0 +1,40 @@
+package itype
+
+import isa "riscv-instruction-encoder/pkg/isa"
+
+// FENCE orders memory accesses and FENCE.I makes stores visible to fetch.
+// A single hart that executes one instruction at a time already sees its
+// accesses in order, so both only advance the PC.
+type FENCE struct {
+	Type
+}
+
+func newFENCE(t Type) *FENCE {
+	inst := &FENCE{Type: t}
+	name := "FENCE"
+	if t.Funct3 == FUNCT3_FENCE_I {
+		name = "FENCE.I"
+	}
+	inst.InstructionMeta = isa.InstructionMeta{
+		Name:           name,
+		OpCode:         uint32(t.OpCode),
+		IsLoad:         false,
+		IsStore:        false,
+		IsBranch:       false,
+		IsJump:         false,
+		IsSystem:       true,
+		WritesRegister: false,
+		ReadsRegister:  false,
+		Rs:             nil,
+		Rd:             nil,
+		ProduceStage:   0,
+		ConsumeStage:   isa.ID,
+	}
+	return inst
+}
+
+func (i *FENCE) ExecuteDecodeInstruction(s *isa.State) {}
+
+func (i *FENCE) ExecuteOperation(s *isa.State) {}
+
+func (i *FENCE) ExecuteWriteBack(s *isa.State) {}
@@ -24,3 +24,8 @@ func newJALR(t Type) *JALR {
 	}
 	return inst
 }
+
+func (i *JALR) ExecuteOperation(s *isa.State) {
+	s.Latch.Result = s.PC + 4
+	s.Latch.NextPC = (s.Latch.A + s.Latch.B) &^ 1
+}
@@ -24,3 +24,16 @@ func newLB(t Type) *LB {
 	}
 	return inst
 }
+
+func (i *LB) ExecuteOperation(s *isa.State) {
+	s.Latch.Address = s.Latch.A + s.Latch.B
+}
+
+func (i *LB) ExecuteAccessOperand(s *isa.State) {
+	value, err := s.Memory.Load(s.Latch.Address, 1)
+	if err != nil {
+		s.Raise(err)
+		return
+	}
+	s.Latch.Result = uint32(isa.SignExtend(value, 8))
+}
@@ -0,0 +1,39 @@
+package itype
+
+import isa "riscv-instruction-encoder/pkg/isa"
+
+type LBU struct {
+	Type
+}
+
+func newLBU(t Type) *LBU {
+	inst := &LBU{Type: t}
+	inst.InstructionMeta = isa.InstructionMeta{
+		Name:           "LBU",
+		OpCode:         uint32(t.OpCode),
+		IsLoad:         true,
+		IsStore:        false,
+		IsBranch:       false,
+		IsJump:         false,
+		WritesRegister: true,
+		ReadsRegister:  true,
+		Rs:             []int{int(t.Rs1)},
+		Rd:             isa.IntPtr(int(t.Rd)),
+		ProduceStage:   isa.MEM,
+		ConsumeStage:   isa.ID,
+	}
+	return inst
+}
+
+func (i *LBU) ExecuteOperation(s *isa.State) {
+	s.Latch.Address = s.Latch.A + s.Latch.B
+}
+
+func (i *LBU) ExecuteAccessOperand(s *isa.State) {
+	value, err := s.Memory.Load(s.Latch.Address, 1)
+	if err != nil {
+		s.Raise(err)
+		return
+	}
+	s.Latch.Result = value
+}
@@ -25,3 +25,16 @@ func newLW(t Type) *LW {
 	}
 	return inst
 }
+
+func (i *LW) ExecuteOperation(s *isa.State) {
+	s.Latch.Address = s.Latch.A + s.Latch.B
+}
+
+func (i *LW) ExecuteAccessOperand(s *isa.State) {
+	value, err := s.Memory.Load(s.Latch.Address, 4)
+	if err != nil {
+		s.Raise(err)
+		return
+	}
+	s.Latch.Result = value
+}
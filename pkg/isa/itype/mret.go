@@ -0,0 +1,39 @@
+package itype
+
+import isa "riscv-instruction-encoder/pkg/isa"
+
+type MRET struct {
+	Type
+}
+
+// MRET – retorno de trap: salta para mepc. Sem modos de privilégio no
+// simulador, mstatus não é alterado; os programas riscv-tests o usam apenas
+// para sair do código de inicialização.
+func newMRET(t Type) *MRET {
+	inst := &MRET{Type: t}
+	inst.InstructionMeta = isa.InstructionMeta{
+		Name:           "MRET",
+		OpCode:         uint32(t.OpCode),
+		IsLoad:         false,
+		IsStore:        false,
+		IsBranch:       false,
+		IsJump:         true,
+		IsSystem:       true,
+		WritesRegister: false,
+		ReadsRegister:  false,
+		Rs:             nil,
+		Rd:             nil,
+		ProduceStage:   0,
+		ConsumeStage:   isa.ID,
+	}
+	return inst
+}
+
+func (i *MRET) ExecuteDecodeInstruction(s *isa.State) {}
+
+func (i *MRET) ExecuteOperation(s *isa.State) {
+	mepc, _ := s.ReadCSR(isa.CSRMepc)
+	s.Latch.NextPC = mepc &^ 0x3
+}
+
+func (i *MRET) ExecuteWriteBack(s *isa.State) {}
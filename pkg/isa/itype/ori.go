@@ -24,3 +24,7 @@ func newORI(t Type) *ORI {
 	}
 	return inst
 }
+
+func (i *ORI) ExecuteOperation(s *isa.State) {
+	s.Latch.Result = s.Latch.A | s.Latch.B
+}
@@ -0,0 +1,35 @@
+package itype
+
+import isa "riscv-instruction-encoder/pkg/isa"
+
+type SLTIU struct {
+	Type
+}
+
+// SLTIU compares with the sign-extended immediate as an unsigned number,
+// so sltiu rd, rs1, 1 sets rd when rs1 is zero.
+func newSLTIU(t Type) *SLTIU {
+	inst := &SLTIU{Type: t}
+	inst.InstructionMeta = isa.InstructionMeta{
+		Name:           "SLTIU",
+		OpCode:         uint32(t.OpCode),
+		IsLoad:         false,
+		IsStore:        false,
+		IsBranch:       false,
+		IsJump:         false,
+		WritesRegister: true,
+		ReadsRegister:  true,
+		Rs:             []int{int(t.Rs1)},
+		Rd:             isa.IntPtr(int(t.Rd)),
+		ProduceStage:   isa.EX,
+		ConsumeStage:   isa.ID,
+	}
+	return inst
+}
+
+func (i *SLTIU) ExecuteOperation(s *isa.State) {
+	s.Latch.Result = 0
+	if s.Latch.A < s.Latch.B {
+		s.Latch.Result = 1
+	}
+}
@@ -0,0 +1,32 @@
+package itype
+
+import isa "riscv-instruction-encoder/pkg/isa"
+
+type SRAI struct {
+	Type
+}
+
+// SRAI is told apart from SRLI by bit 30, which sits above the shamt in
+// the immediate.
+func newSRAI(t Type) *SRAI {
+	inst := &SRAI{Type: t}
+	inst.InstructionMeta = isa.InstructionMeta{
+		Name:           "SRAI",
+		OpCode:         uint32(t.OpCode),
+		IsLoad:         false,
+		IsStore:        false,
+		IsBranch:       false,
+		IsJump:         false,
+		WritesRegister: true,
+		ReadsRegister:  true,
+		Rs:             []int{int(t.Rs1)},
+		Rd:             isa.IntPtr(int(t.Rd)),
+		ProduceStage:   isa.EX,
+		ConsumeStage:   isa.ID,
+	}
+	return inst
+}
+
+func (i *SRAI) ExecuteOperation(s *isa.State) {
+	s.Latch.Result = uint32(int32(s.Latch.A) >> (s.Latch.B & 0x1F))
+}
@@ -7,22 +7,61 @@ import (
 
 // Definição de opcodes como constantes
 const (
-	OP_IMM  = 0x13 // ADDI, ORI, ANDI, etc.
-	OP_LOAD = 0x03 // LB, LW, etc.
-	OP_JALR = 0x67
+	OP_IMM   = 0x13 // ADDI, ORI, ANDI, etc.
+	OP_LOAD  = 0x03 // LB, LW, etc.
+	OP_JALR  = 0x67
+	SYSTEM   = 0x73 // ECALL, EBREAK, MRET, CSR*
+	MISC_MEM = 0x0F // FENCE, FENCE.I
+)
+
+// Definição de imm para SYSTEM com funct3 = 0
+const (
+	IMM_ECALL  = 0x0
+	IMM_EBREAK = 0x1
+	IMM_MRET   = 0x302
+)
+
+// Definição de funct3 para SYSTEM (Zicsr)
+const (
+	FUNCT3_CSRRW  = 0x1
+	FUNCT3_CSRRS  = 0x2
+	FUNCT3_CSRRC  = 0x3
+	FUNCT3_CSRRWI = 0x5
+	FUNCT3_CSRRSI = 0x6
+	FUNCT3_CSRRCI = 0x7
+)
+
+// Definição de funct3 para MISC_MEM
+const (
+	FUNCT3_FENCE   = 0x0
+	FUNCT3_FENCE_I = 0x1
 )
 
 // Definição de funct3 para OP_IMM
 const (
-	FUNCT3_ADDI = 0x0
-	FUNCT3_ORI  = 0x6
-	FUNCT3_ANDI = 0x7
+	FUNCT3_ADDI  = 0x0
+	FUNCT3_SLLI  = 0x1
+	FUNCT3_SLTI  = 0x2
+	FUNCT3_SLTIU = 0x3
+	FUNCT3_XORI  = 0x4
+	FUNCT3_SRI   = 0x5 // SRLI, SRAI
+	FUNCT3_ORI   = 0x6
+	FUNCT3_ANDI  = 0x7
+)
+
+// Definição de funct7 (imm[11:5]) para os deslocamentos
+const (
+	FUNCT7_SRLI = 0x00
+	FUNCT7_SRAI = 0x20
 )
 
 // Definição de funct3 para LOAD
 const (
-	FUNCT3_LB = 0x0
-	FUNCT3_LW = 0x2
+	FUNCT3_LB  = 0x0
+	FUNCT3_LH  = 0x1
+	FUNCT3_LW  = 0x2
+	FUNCT3_LBU = 0x4
+	FUNCT3_LHU = 0x5
 )
 
 type Type struct {
@@ -43,6 +82,11 @@ func (i *Type) Decode(inst uint32) isa.Instruction {
 	return i.findInstruction()
 }
 
+func (i *Type) Encode() uint32 {
+	return uint32(i.Imm)<<20 | uint32(i.Rs1)<<15 | uint32(i.Funct3)<<12 |
+		uint32(i.Rd)<<7 | uint32(i.OpCode)
+}
+
 func (i *Type) String() string {
 	return fmt.Sprintf("%s {opcode=%02X, rd=%d, funct3=%d, rs1=%d, imm=%d}",
 		i.InstructionMeta.Name, i.OpCode, i.Rd, i.Funct3, i.Rs1, i.Imm)
@@ -54,41 +98,76 @@ func (i *Type) findInstruction() isa.Instruction {
 		switch i.Funct3 {
 		case FUNCT3_ADDI:
 			return newADDI(*i)
+		case FUNCT3_SLTI:
+			return newSLTI(*i)
+		case FUNCT3_SLTIU:
+			return newSLTIU(*i)
+		case FUNCT3_XORI:
+			return newXORI(*i)
 		case FUNCT3_ORI:
 			return newORI(*i)
 		case FUNCT3_ANDI:
 			return NewANDI(*i)
+		case FUNCT3_SLLI:
+			if i.Imm>>5 == 0 {
+				return newSLLI(*i)
+			}
+		case FUNCT3_SRI:
+			switch i.Imm >> 5 {
+			case FUNCT7_SRLI:
+				return newSRLI(*i)
+			case FUNCT7_SRAI:
+				return newSRAI(*i)
+			}
 		}
 	case OP_LOAD:
 		switch i.Funct3 {
-		case FUNCT3_LW:
-			return newLW(*i)
 		case FUNCT3_LB:
 			return newLB(*i)
+		case FUNCT3_LH:
+			return newLH(*i)
+		case FUNCT3_LW:
+			return newLW(*i)
+		case FUNCT3_LBU:
+			return newLBU(*i)
+		case FUNCT3_LHU:
+			return newLHU(*i)
 		}
 	case OP_JALR:
 		return newJALR(*i)
+	case MISC_MEM:
+		switch i.Funct3 {
+		case FUNCT3_FENCE, FUNCT3_FENCE_I:
+			return newFENCE(*i)
+		}
+	case SYSTEM:
+		if _, ok := csrNames[i.Funct3]; ok {
+			return newCSR(*i)
+		}
+		if i.Funct3 == 0 && i.Rd == 0 && i.Rs1 == 0 {
+			switch i.Imm {
+			case IMM_ECALL:
+				return newECALL(*i)
+			case IMM_EBREAK:
+				return newEBREAK(*i)
+			case IMM_MRET:
+				return newMRET(*i)
+			}
+		}
 	}
 	return i
 }
 
 // Stages
-func (t *Type) ExecuteFetchInstruction() {
-	fmt.Printf("[IF ] Fetching instruction: %s\n", t.InstructionMeta.Name)
-}
-
-func (t *Type) ExecuteDecodeInstruction() {
-	fmt.Printf("[ID ] Decoding instruction: %s\n", t.InstructionMeta.Name)
-}
-
-func (t *Type) ExecuteOperation() {
-	fmt.Printf("[EX ] Executing operation for instruction: %s\n", t.InstructionMeta.Name)
+func (t *Type) ExecuteDecodeInstruction(s *isa.State) {
+	s.Latch.A = s.ReadReg(t.Rs1)
+	s.Latch.B = uint32(isa.SignExtend(uint32(t.Imm), 12))
 }
 
-func (t *Type) ExecuteAccessOperand() {
-	fmt.Printf("[MEM] Accessing operands/memory for instruction: %s\n", t.InstructionMeta.Name)
+func (t *Type) ExecuteOperation(s *isa.State) {
+	s.IllegalInstruction(t.String())
 }
 
-func (t *Type) ExecuteWriteBack() {
-	fmt.Printf("[WB ] Writing back result of instruction: %s\n", t.InstructionMeta.Name)
+func (t *Type) ExecuteWriteBack(s *isa.State) {
+	s.WriteReg(t.Rd, s.Latch.Result)
 }
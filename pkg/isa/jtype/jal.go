@@ -26,3 +26,8 @@ func newJAL(t Type) *JAL {
 
 	return inst
 }
+
+func (i *JAL) ExecuteOperation(s *isa.State) {
+	s.Latch.Result = s.PC + 4
+	s.Latch.NextPC = i.Target(s.PC)
+}
@@ -29,6 +29,35 @@ func (j *Type) String() string {
 		j.getInstructionName(), j.OpCode, j.Rd, j.Imm)
 }
 
+// Offset reassembles the scrambled J-immediate (imm[20|10:1|11|19:12]).
+func (j *Type) Offset() int32 {
+	imm20 := (j.Imm >> 19) & 0x1
+	imm10_1 := (j.Imm >> 9) & 0x3FF
+	imm11 := (j.Imm >> 8) & 0x1
+	imm19_12 := j.Imm & 0xFF
+	return isa.SignExtend((imm20<<20)|(imm19_12<<12)|(imm11<<11)|(imm10_1<<1), 21)
+}
+
+func (j *Type) Target(pc uint32) uint32 {
+	return pc + uint32(j.Offset())
+}
+
+func (j *Type) Encode() uint32 {
+	return j.Imm<<12 | uint32(j.Rd)<<7 | uint32(j.OpCode)
+}
+
+// Retarget re-encodes the jump offset; JAL reaches ±1 MiB.
+func (j *Type) Retarget(pc, target uint32) (isa.Instruction, error) {
+	offset := int32(target - pc)
+	if offset < -(1<<20) || offset >= 1<<20 || offset&1 != 0 {
+		return nil, fmt.Errorf("salto de 0x%08X para 0x%08X fora do alcance", pc, target)
+	}
+	imm := uint32(offset)
+	moved := *j
+	moved.Imm = (imm>>20&0x1)<<19 | (imm>>1&0x3FF)<<9 | (imm>>11&0x1)<<8 | imm>>12&0xFF
+	return moved.findInstruction(), nil
+}
+
 func (j *Type) findInstruction() isa.Instruction {
 	switch j.OpCode {
 	case OP_JAL:
@@ -45,22 +74,10 @@ func (j *Type) getInstructionName() string {
 	return "UNKNOWN_J"
 }
 
-func (j *Type) ExecuteFetchInstruction() {
-	fmt.Printf("[IF ] Fetching instruction: %s\n", j.getInstructionName())
-}
-
-func (j *Type) ExecuteDecodeInstruction() {
-	fmt.Printf("[ID ] Decoding instruction: %s\n", j.getInstructionName())
-}
-
-func (j *Type) ExecuteOperation() {
-	fmt.Printf("[EX ] Executing operation for instruction: %s\n", j.getInstructionName())
-}
-
-func (j *Type) ExecuteAccessOperand() {
-	fmt.Printf("[MEM] Accessing operands/memory for instruction: %s\n", j.getInstructionName())
+func (j *Type) ExecuteOperation(s *isa.State) {
+	s.IllegalInstruction(j.String())
 }
 
-func (j *Type) ExecuteWriteBack() {
-	fmt.Printf("[WB ] Writing back result of instruction: %s\n", j.getInstructionName())
+func (j *Type) ExecuteWriteBack(s *isa.State) {
+	s.WriteReg(j.Rd, s.Latch.Result)
 }
@@ -25,28 +25,18 @@ func (i *NOP) Decode(inst uint32) Instruction {
 	return NewNOP()
 }
 
+// Encode returns the canonical NOP, addi x0, x0, 0.
+func (i *NOP) Encode() uint32 {
+	return 0x00000013
+}
+
 func (i *NOP) String() string {
 	return fmt.Sprintf("%s",
 		i.InstructionMeta.Name)
 }
 
-// Stages
-func (t *NOP) ExecuteFetchInstruction() {
-	fmt.Printf("[IF ] Fetching instruction: %s\n", t.InstructionMeta.Name)
-}
-
-func (t *NOP) ExecuteDecodeInstruction() {
-	fmt.Printf("[ID ] Decoding instruction: %s\n", t.InstructionMeta.Name)
-}
-
-func (t *NOP) ExecuteOperation() {
-	fmt.Printf("[EX ] Executing operation for instruction: %s\n", t.InstructionMeta.Name)
-}
-
-func (t *NOP) ExecuteAccessOperand() {
-	fmt.Printf("[MEM] Accessing operands/memory for instruction: %s\n", t.InstructionMeta.Name)
-}
-
-func (t *NOP) ExecuteWriteBack() {
-	fmt.Printf("[WB ] Writing back result of instruction: %s\n", t.InstructionMeta.Name)
+// IsNOP reports whether inst is the canonical NOP, whether inserted by the
+// tool or decoded from addi x0, x0, 0.
+func IsNOP(inst Instruction) bool {
+	return inst.Encode() == NewNOP().Encode()
 }
@@ -26,6 +26,11 @@ func (r *Type) Decode(inst uint32) isa.Instruction {
 	return r.findInstruction(r.Funct3, r.Funct7)
 }
 
+func (r *Type) Encode() uint32 {
+	return uint32(r.Funct7)<<25 | uint32(r.Rs2)<<20 | uint32(r.Rs1)<<15 |
+		uint32(r.Funct3)<<12 | uint32(r.Rd)<<7 | uint32(r.Opcode)
+}
+
 func (r *Type) String() string {
 	return fmt.Sprintf("%s {opcode=%02X, rd=%d, funct3=%d, rs1=%d, rs2=%d, funct7=%d}",
 		r.InstructionMeta.Name, r.Opcode, r.Rd, r.Funct3, r.Rs1, r.Rs2, r.Funct7)
@@ -36,7 +41,41 @@ func (r *Type) findInstruction(funct3 uint8, funct7 uint8) isa.Instruction {
 		return newADD(*r)
 	case funct7 == 0x20 && funct3 == 0x00:
 		return newSUB(*r)
+	case funct7 == 0x00 && funct3 == 0x01:
+		return newSLL(*r)
+	case funct7 == 0x00 && funct3 == 0x02:
+		return newSLT(*r)
+	case funct7 == 0x00 && funct3 == 0x03:
+		return newSLTU(*r)
+	case funct7 == 0x00 && funct3 == 0x04:
+		return newXOR(*r)
+	case funct7 == 0x00 && funct3 == 0x05:
+		return newSRL(*r)
+	case funct7 == 0x20 && funct3 == 0x05:
+		return newSRA(*r)
+	case funct7 == 0x00 && funct3 == 0x06:
+		return newOR(*r)
+	case funct7 == 0x00 && funct3 == 0x07:
+		return newAND(*r)
+	case funct7 == 0x01 && funct3 == 0x00:
+		return newMUL(*r)
+	case funct7 == 0x01 && funct3 == 0x04:
+		return newDIV(*r)
 	}
 
 	return r
 }
+
+// Stages
+func (r *Type) ExecuteDecodeInstruction(s *isa.State) {
+	s.Latch.A = s.ReadReg(r.Rs1)
+	s.Latch.B = s.ReadReg(r.Rs2)
+}
+
+func (r *Type) ExecuteOperation(s *isa.State) {
+	s.IllegalInstruction(r.String())
+}
+
+func (r *Type) ExecuteWriteBack(s *isa.State) {
+	s.WriteReg(r.Rd, s.Latch.Result)
+}
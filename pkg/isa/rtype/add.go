@@ -26,3 +26,7 @@ func newADD(t Type) *ADD {
 
 	return inst
 }
+
+func (i *ADD) ExecuteOperation(s *isa.State) {
+	s.Latch.Result = s.Latch.A + s.Latch.B
+}
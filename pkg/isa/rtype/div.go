@@ -0,0 +1,46 @@
+package rtype
+
+import (
+	"math"
+	isa "riscv-instruction-encoder/pkg/isa"
+)
+
+type DIV struct {
+	Type
+}
+
+func newDIV(t Type) *DIV {
+	inst := &DIV{Type: t}
+
+	inst.InstructionMeta = isa.InstructionMeta{
+		Name:           "DIV",
+		OpCode:         uint32(t.Opcode),
+		IsLoad:         false,
+		IsStore:        false,
+		IsBranch:       false,
+		IsJump:         false,
+		WritesRegister: true,
+		ReadsRegister:  true,
+		Rs:             []int{(int(t.Rs1)), (int(t.Rs2))},
+		Rd:             isa.IntPtr(int(t.Rd)),
+		ProduceStage:   isa.EX,
+		ConsumeStage:   isa.ID,
+	}
+
+	return inst
+}
+
+// ExecuteOperation divides signed operands rounding towards zero. As in the
+// M extension, division by zero gives -1 and the overflowing -2^31 / -1
+// gives -2^31, without trapping.
+func (i *DIV) ExecuteOperation(s *isa.State) {
+	a, b := int32(s.Latch.A), int32(s.Latch.B)
+	switch {
+	case b == 0:
+		s.Latch.Result = 0xFFFFFFFF
+	case a == math.MinInt32 && b == -1:
+		s.Latch.Result = s.Latch.A
+	default:
+		s.Latch.Result = uint32(a / b)
+	}
+}
@@ -0,0 +1,34 @@
+package rtype
+
+import isa "riscv-instruction-encoder/pkg/isa"
+
+type MUL struct {
+	Type
+}
+
+func newMUL(t Type) *MUL {
+	inst := &MUL{Type: t}
+
+	inst.InstructionMeta = isa.InstructionMeta{
+		Name:           "MUL",
+		OpCode:         uint32(t.Opcode),
+		IsLoad:         false,
+		IsStore:        false,
+		IsBranch:       false,
+		IsJump:         false,
+		WritesRegister: true,
+		ReadsRegister:  true,
+		Rs:             []int{(int(t.Rs1)), (int(t.Rs2))},
+		Rd:             isa.IntPtr(int(t.Rd)),
+		ProduceStage:   isa.EX,
+		ConsumeStage:   isa.ID,
+	}
+
+	return inst
+}
+
+// ExecuteOperation keeps the low 32 bits of the product, which are the same
+// for signed and unsigned operands.
+func (i *MUL) ExecuteOperation(s *isa.State) {
+	s.Latch.Result = s.Latch.A * s.Latch.B
+}
@@ -0,0 +1,31 @@
+package rtype
+
+import isa "riscv-instruction-encoder/pkg/isa"
+
+type SLL struct {
+	Type
+}
+
+// SLL shifts by the low five bits of rs2.
+func newSLL(t Type) *SLL {
+	inst := &SLL{Type: t}
+	inst.InstructionMeta = isa.InstructionMeta{
+		Name:           "SLL",
+		OpCode:         uint32(t.Opcode),
+		IsLoad:         false,
+		IsStore:        false,
+		IsBranch:       false,
+		IsJump:         false,
+		WritesRegister: true,
+		ReadsRegister:  true,
+		Rs:             []int{int(t.Rs1), int(t.Rs2)},
+		Rd:             isa.IntPtr(int(t.Rd)),
+		ProduceStage:   isa.EX,
+		ConsumeStage:   isa.ID,
+	}
+	return inst
+}
+
+func (i *SLL) ExecuteOperation(s *isa.State) {
+	s.Latch.Result = s.Latch.A << (s.Latch.B & 0x1F)
+}
@@ -0,0 +1,33 @@
+package rtype
+
+import isa "riscv-instruction-encoder/pkg/isa"
+
+type SLTU struct {
+	Type
+}
+
+func newSLTU(t Type) *SLTU {
+	inst := &SLTU{Type: t}
+	inst.InstructionMeta = isa.InstructionMeta{
+		Name:           "SLTU",
+		OpCode:         uint32(t.Opcode),
+		IsLoad:         false,
+		IsStore:        false,
+		IsBranch:       false,
+		IsJump:         false,
+		WritesRegister: true,
+		ReadsRegister:  true,
+		Rs:             []int{int(t.Rs1), int(t.Rs2)},
+		Rd:             isa.IntPtr(int(t.Rd)),
+		ProduceStage:   isa.EX,
+		ConsumeStage:   isa.ID,
+	}
+	return inst
+}
+
+func (i *SLTU) ExecuteOperation(s *isa.State) {
+	s.Latch.Result = 0
+	if s.Latch.A < s.Latch.B {
+		s.Latch.Result = 1
+	}
+}
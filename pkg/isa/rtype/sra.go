@@ -0,0 +1,31 @@
+package rtype
+
+import isa "riscv-instruction-encoder/pkg/isa"
+
+type SRA struct {
+	Type
+}
+
+// SRA shifts by the low five bits of rs2.
+func newSRA(t Type) *SRA {
+	inst := &SRA{Type: t}
+	inst.InstructionMeta = isa.InstructionMeta{
+		Name:           "SRA",
+		OpCode:         uint32(t.Opcode),
+		IsLoad:         false,
+		IsStore:        false,
+		IsBranch:       false,
+		IsJump:         false,
+		WritesRegister: true,
+		ReadsRegister:  true,
+		Rs:             []int{int(t.Rs1), int(t.Rs2)},
+		Rd:             isa.IntPtr(int(t.Rd)),
+		ProduceStage:   isa.EX,
+		ConsumeStage:   isa.ID,
+	}
+	return inst
+}
+
+func (i *SRA) ExecuteOperation(s *isa.State) {
+	s.Latch.Result = uint32(int32(s.Latch.A) >> (s.Latch.B & 0x1F))
+}
@@ -26,3 +26,7 @@ func newSUB(t Type) *SUB {
 
 	return inst
 }
+
+func (i *SUB) ExecuteOperation(s *isa.State) {
+	s.Latch.Result = s.Latch.A - s.Latch.B
+}
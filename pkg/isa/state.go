@@ -0,0 +1,81 @@
+package isa
+
+import "fmt"
+
+// Memory is the byte-addressable store behind loads, stores and fetch.
+// Values are little-endian and size is 1, 2 or 4 bytes.
+type Memory interface {
+	Load(addr uint32, size int) (uint32, error)
+	Store(addr uint32, size int, value uint32) error
+}
+
+// Environment services the requests a program makes to its execution
+// environment through ECALL and EBREAK. Poll runs after every instruction so
+// memory-mapped conventions such as tohost can be observed.
+type Environment interface {
+	Ecall(s *State)
+	Ebreak(s *State)
+	Poll(s *State)
+}
+
+// Latch carries the values an instruction produces from one stage to the
+// next, like the IF/ID, ID/EX, EX/MEM and MEM/WB pipeline registers.
+type Latch struct {
+	A       uint32 // rs1, read in ID
+	B       uint32 // rs2 or the sign-extended immediate, read in ID
+	Result  uint32 // ALU result, link address or loaded value
+	Address uint32 // effective address of loads and stores
+	NextPC  uint32
+}
+
+// State is the architectural state of a RV32I hart.
+type State struct {
+	Regs   [32]uint32
+	PC     uint32
+	Memory Memory
+	Env    Environment
+	Latch  Latch
+	Trap   error
+	Halted bool
+	// Instret counts the retired instructions, read through the cycle,
+	// time and instret CSRs.
+	Instret uint64
+	csrs    map[uint16]uint32
+}
+
+var RegisterNames = [32]string{
+	"zero", "ra", "sp", "gp", "tp", "t0", "t1", "t2",
+	"s0", "s1", "a0", "a1", "a2", "a3", "a4", "a5",
+	"a6", "a7", "s2", "s3", "s4", "s5", "s6", "s7",
+	"s8", "s9", "s10", "s11", "t3", "t4", "t5", "t6",
+}
+
+func NewState(memory Memory) *State {
+	return &State{Memory: memory}
+}
+
+func (s *State) ReadReg(r uint8) uint32 {
+	if r == 0 {
+		return 0
+	}
+	return s.Regs[r]
+}
+
+// WriteReg discards writes to x0, which is hard-wired to zero.
+func (s *State) WriteReg(r uint8, value uint32) {
+	if r == 0 {
+		return
+	}
+	s.Regs[r] = value
+}
+
+// Raise records the first trap of the current instruction.
+func (s *State) Raise(err error) {
+	if s.Trap == nil {
+		s.Trap = err
+	}
+}
+
+func (s *State) IllegalInstruction(name string) {
+	s.Raise(fmt.Errorf("instrução sem semântica implementada: %s em 0x%08X", name, s.PC))
+}
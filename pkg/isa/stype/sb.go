@@ -0,0 +1,32 @@
+package stype
+
+import isa "riscv-instruction-encoder/pkg/isa"
+
+type SB struct {
+	Type
+}
+
+func newSB(t Type) *SB {
+	inst := &SB{Type: t}
+	inst.InstructionMeta = isa.InstructionMeta{
+		Name:           "SB",
+		OpCode:         uint32(t.OpCode),
+		IsLoad:         false,
+		IsStore:        true,
+		IsBranch:       false,
+		IsJump:         false,
+		WritesRegister: false,
+		ReadsRegister:  true,
+		Rs:             []int{int(t.Rs1), int(t.Rs2)},
+		Rd:             nil,
+		ProduceStage:   0,
+		ConsumeStage:   isa.ID,
+	}
+	return inst
+}
+
+func (i *SB) ExecuteAccessOperand(s *isa.State) {
+	if err := s.Memory.Store(s.Latch.Address, 1, s.Latch.B); err != nil {
+		s.Raise(err)
+	}
+}
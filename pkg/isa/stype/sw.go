@@ -24,3 +24,9 @@ func newSW(t Type) *SW {
 	}
 	return inst
 }
+
+func (i *SW) ExecuteAccessOperand(s *isa.State) {
+	if err := s.Memory.Store(s.Latch.Address, 4, s.Latch.B); err != nil {
+		s.Raise(err)
+	}
+}
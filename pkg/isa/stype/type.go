@@ -37,6 +37,12 @@ func (s *Type) Decode(inst uint32) isa.Instruction {
 	return s.findInstruction()
 }
 
+func (s *Type) Encode() uint32 {
+	imm := uint32(s.Imm)
+	return (imm>>5)<<25 | uint32(s.Rs2)<<20 | uint32(s.Rs1)<<15 |
+		uint32(s.Funct3)<<12 | (imm&0x1F)<<7 | uint32(s.OpCode)
+}
+
 func (s *Type) String() string {
 	return fmt.Sprintf("%s {opcode=%02X, funct3=%d, rs1=%d, rs2=%d, imm=%d}",
 		s.getInstructionName(), s.OpCode, s.Funct3, s.Rs1, s.Rs2, s.Imm)
@@ -60,28 +66,28 @@ func (s *Type) getInstructionName() string {
 func (s *Type) findInstruction() isa.Instruction {
 	switch s.OpCode {
 	case STORE:
-		return newSW(*s) // default para SW, adapte se quiser SH/SB
+		switch s.Funct3 {
+		case FUNCT3_SB:
+			return newSB(*s)
+		case FUNCT3_SH:
+			return newSH(*s)
+		case FUNCT3_SW:
+			return newSW(*s)
+		}
 	}
 	return s
 }
 
 // Pipeline stages
-func (s *Type) ExecuteFetchInstruction() {
-	fmt.Printf("[IF ] Fetching instruction: %s\n", s.getInstructionName())
-}
-
-func (s *Type) ExecuteDecodeInstruction() {
-	fmt.Printf("[ID ] Decoding instruction: %s\n", s.getInstructionName())
-}
-
-func (s *Type) ExecuteOperation() {
-	fmt.Printf("[EX ] Executing operation for instruction: %s\n", s.getInstructionName())
+func (s *Type) ExecuteDecodeInstruction(st *isa.State) {
+	st.Latch.A = st.ReadReg(s.Rs1)
+	st.Latch.B = st.ReadReg(s.Rs2)
 }
 
-func (s *Type) ExecuteAccessOperand() {
-	fmt.Printf("[MEM] Accessing operands/memory for instruction: %s\n", s.getInstructionName())
+func (s *Type) ExecuteOperation(st *isa.State) {
+	st.Latch.Address = st.Latch.A + uint32(isa.SignExtend(uint32(s.Imm), 12))
 }
 
-func (s *Type) ExecuteWriteBack() {
-	fmt.Printf("[WB ] Writing back result of instruction: %s\n", s.getInstructionName())
+func (s *Type) ExecuteAccessOperand(st *isa.State) {
+	st.IllegalInstruction(s.String())
 }
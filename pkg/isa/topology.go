@@ -0,0 +1,306 @@
+package isa
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Class groups the instructions that produce and consume their operands at
+// the same points of a pipeline.
+type Class int
+
+const (
+	ClassALU Class = iota
+	ClassLoad
+	ClassStore
+	ClassBranch
+	ClassJump
+	ClassSystem
+)
+
+var classNames = map[string]Class{
+	"alu":    ClassALU,
+	"load":   ClassLoad,
+	"store":  ClassStore,
+	"branch": ClassBranch,
+	"jump":   ClassJump,
+	"system": ClassSystem,
+}
+
+// ClassOf returns the class of an instruction. JALR counts as a jump.
+func ClassOf(meta InstructionMeta) Class {
+	switch {
+	case meta.IsLoad:
+		return ClassLoad
+	case meta.IsStore:
+		return ClassStore
+	case meta.IsJump:
+		return ClassJump
+	case meta.IsBranch:
+		return ClassBranch
+	case meta.IsSystem:
+		return ClassSystem
+	}
+	return ClassALU
+}
+
+// Points are where an instruction class needs its source registers and
+// where its result can first be forwarded; Produce is zero for classes that
+// write no register.
+type Points struct {
+	Produce Stage
+	Consume Stage
+}
+
+// validate checks that a class reads its operands at some stage and does
+// not produce its result before reading them or after the write back.
+func (p Points) validate(writeBack Stage) error {
+	if p.Consume == 0 {
+		return fmt.Errorf("estágio de consumo obrigatório")
+	}
+	if p.Produce != 0 && (p.Produce < p.Consume || p.Produce > writeBack) {
+		return fmt.Errorf("produção deve ficar entre o consumo e o writeback")
+	}
+	return nil
+}
+
+func className(class Class) string {
+	for name, c := range classNames {
+		if c == class {
+			return name
+		}
+	}
+	return fmt.Sprintf("classe %d", int(class))
+}
+
+// Topology describes an in-order pipeline: its stages in order, numbered
+// from 1 (the fetch stage), and where each instruction class produces and
+// consumes values.
+type Topology struct {
+	Name   string
+	Stages []string
+	Points map[Class]Points
+	// WriteBack is the stage that writes the register file, where results
+	// come from without forwarding.
+	WriteBack Stage
+	// Execute is the stage where the ALU compares branch operands.
+	Execute Stage
+	// Memory is the stage where loads and stores access data memory.
+	Memory Stage
+	// Resolve is where branches and jumps know their outcome unless the
+	// pipeline configuration moves it.
+	Resolve Stage
+}
+
+// FiveStage is the classic IF ID EX MEM WB pipeline.
+var FiveStage = &Topology{
+	Name:   "5stage",
+	Stages: []string{"IF", "ID", "EX", "MEM", "WB"},
+	Points: map[Class]Points{
+		ClassALU:    {Produce: EX, Consume: ID},
+		ClassLoad:   {Produce: MEM, Consume: ID},
+		ClassStore:  {Consume: ID},
+		ClassBranch: {Consume: ID},
+		ClassJump:   {Produce: EX, Consume: ID},
+		ClassSystem: {Produce: EX, Consume: ID},
+	},
+	WriteBack: WB,
+	Execute:   EX,
+	Memory:    MEM,
+	Resolve:   MEM,
+}
+
+// ThreeStage is a microcontroller core whose last stage executes, accesses
+// memory and writes back.
+var ThreeStage = &Topology{
+	Name:   "3stage",
+	Stages: []string{"IF", "ID", "EX"},
+	Points: map[Class]Points{
+		ClassALU:    {Produce: 3, Consume: 2},
+		ClassLoad:   {Produce: 3, Consume: 2},
+		ClassStore:  {Consume: 2},
+		ClassBranch: {Consume: 2},
+		ClassJump:   {Produce: 3, Consume: 2},
+		ClassSystem: {Produce: 3, Consume: 2},
+	},
+	WriteBack: 3,
+	Execute:   3,
+	Memory:    3,
+	Resolve:   3,
+}
+
+// SevenStage splits fetch over two cycles and takes two cycles to access
+// data memory.
+var SevenStage = &Topology{
+	Name:   "7stage",
+	Stages: []string{"IF1", "IF2", "ID", "EX", "MEM1", "MEM2", "WB"},
+	Points: map[Class]Points{
+		ClassALU:    {Produce: 4, Consume: 3},
+		ClassLoad:   {Produce: 6, Consume: 3},
+		ClassStore:  {Consume: 3},
+		ClassBranch: {Consume: 3},
+		ClassJump:   {Produce: 4, Consume: 3},
+		ClassSystem: {Produce: 4, Consume: 3},
+	},
+	WriteBack: 7,
+	Execute:   4,
+	Memory:    5,
+	Resolve:   5,
+}
+
+var topologies = []*Topology{FiveStage, ThreeStage, SevenStage}
+
+func (t *Topology) Len() int {
+	return len(t.Stages)
+}
+
+// StageName returns the name of stage, numbered from 1.
+func (t *Topology) StageName(stage Stage) string {
+	if stage >= 1 && int(stage) <= len(t.Stages) {
+		return t.Stages[stage-1]
+	}
+	return fmt.Sprintf("S%d", int(stage))
+}
+
+// ParseStage accepts a stage name in any case, e.g. "ex".
+func (t *Topology) ParseStage(name string) (Stage, error) {
+	for i, stage := range t.Stages {
+		if strings.EqualFold(stage, name) {
+			return Stage(i + 1), nil
+		}
+	}
+	return 0, fmt.Errorf("estágio inválido: %s", name)
+}
+
+func (t *Topology) Produce(meta InstructionMeta) Stage {
+	return t.Points[ClassOf(meta)].Produce
+}
+
+func (t *Topology) Consume(meta InstructionMeta) Stage {
+	return t.Points[ClassOf(meta)].Consume
+}
+
+func (t *Topology) String() string {
+	return strings.Join(t.Stages, " ")
+}
+
+// LoadTopology returns the preset called name (5stage, 3stage or 7stage) or
+// reads a description file such as:
+//
+//	stages    IF1 IF2 ID EX MEM1 MEM2 WB
+//	writeback WB
+//	execute   EX
+//	memory    MEM1
+//	resolve   MEM1
+//	alu       EX   ID
+//	load      MEM2 ID
+//	store     -    ID
+//
+// Each class line gives the produce and consume stages, "-" for no produce
+// stage. The alu, load and store lines are required; branch, jump and
+// system default to the points of alu, with no produce stage for branch.
+// Lines starting with # are comments.
+func LoadTopology(name string) (*Topology, error) {
+	for _, t := range topologies {
+		if strings.EqualFold(t.Name, name) {
+			return t, nil
+		}
+	}
+
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("pipeline desconhecido: %s", name)
+	}
+	defer file.Close()
+
+	t := &Topology{Name: name, Points: make(map[Class]Points)}
+	stage := func(field string) (Stage, error) {
+		if field == "-" {
+			return 0, nil
+		}
+		return t.ParseStage(field)
+	}
+
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		key := strings.ToLower(fields[0])
+		if key == "stages" {
+			t.Stages = fields[1:]
+			continue
+		}
+		if t.Stages == nil {
+			return nil, fmt.Errorf("%s:%d: estágios devem ser declarados primeiro", name, line)
+		}
+
+		var stages []Stage
+		for _, field := range fields[1:] {
+			s, err := stage(field)
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: %v", name, line, err)
+			}
+			stages = append(stages, s)
+		}
+		class, isClass := classNames[key]
+		switch {
+		case isClass && len(stages) == 2:
+			t.Points[class] = Points{Produce: stages[0], Consume: stages[1]}
+		case key == "writeback" && len(stages) == 1:
+			t.WriteBack = stages[0]
+		case key == "execute" && len(stages) == 1:
+			t.Execute = stages[0]
+		case key == "memory" && len(stages) == 1:
+			t.Memory = stages[0]
+		case key == "resolve" && len(stages) == 1:
+			t.Resolve = stages[0]
+		default:
+			return nil, fmt.Errorf("%s:%d: linha inválida: %s", name, line, scanner.Text())
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(t.Stages) < 2 || t.WriteBack == 0 {
+		return nil, fmt.Errorf("%s: são necessários estágios e writeback", name)
+	}
+	for _, required := range []string{"alu", "load", "store"} {
+		if _, ok := t.Points[classNames[required]]; !ok {
+			return nil, fmt.Errorf("%s: falta a classe %s", name, required)
+		}
+	}
+	alu := t.Points[ClassALU]
+	defaults := map[Class]Points{
+		ClassBranch: {Consume: alu.Consume},
+		ClassJump:   alu,
+		ClassSystem: alu,
+	}
+	for class, points := range defaults {
+		if _, ok := t.Points[class]; !ok {
+			t.Points[class] = points
+		}
+	}
+	for class, points := range t.Points {
+		if err := points.validate(t.WriteBack); err != nil {
+			return nil, fmt.Errorf("%s: classe %s: %v", name, className(class), err)
+		}
+	}
+	if t.Execute == 0 {
+		t.Execute = t.WriteBack
+	}
+	if t.Resolve == 0 {
+		t.Resolve = t.WriteBack
+	}
+	if t.Memory == 0 {
+		t.Memory = t.Points[ClassLoad].Produce
+	}
+	if t.Memory == 0 {
+		t.Memory = t.Execute
+	}
+	return t, nil
+}
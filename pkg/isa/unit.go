@@ -0,0 +1,46 @@
+package isa
+
+// Unit is the kind of functional unit that executes an instruction.
+type Unit int
+
+const (
+	UnitALU Unit = iota
+	UnitMUL
+	UnitDIV
+	UnitLSU
+	UnitBRU
+	NumUnits
+)
+
+func (u Unit) String() string {
+	switch u {
+	case UnitALU:
+		return "ALU"
+	case UnitMUL:
+		return "MUL"
+	case UnitDIV:
+		return "DIV"
+	case UnitLSU:
+		return "LSU"
+	case UnitBRU:
+		return "BRU"
+	}
+	return "?"
+}
+
+// UnitOf returns the unit an instruction executes on: loads and stores on
+// the load/store unit, branches and jumps on the branch unit, multiplies
+// and divides on their own units and everything else on the ALU.
+func UnitOf(meta InstructionMeta) Unit {
+	switch {
+	case meta.IsLoad || meta.IsStore:
+		return UnitLSU
+	case meta.IsControl():
+		return UnitBRU
+	case meta.Name == "MUL":
+		return UnitMUL
+	case meta.Name == "DIV":
+		return UnitDIV
+	}
+	return UnitALU
+}
@@ -0,0 +1,32 @@
+package utype
+
+import isa "riscv-instruction-encoder/pkg/isa"
+
+type AUIPC struct {
+	Type
+}
+
+func newAUIPC(t Type) *AUIPC {
+	inst := &AUIPC{Type: t}
+
+	inst.InstructionMeta = isa.InstructionMeta{
+		Name:           "AUIPC",
+		OpCode:         uint32(t.Opcode),
+		IsLoad:         false,
+		IsStore:        false,
+		IsBranch:       false,
+		IsJump:         false,
+		WritesRegister: true,
+		ReadsRegister:  false,
+		Rs:             nil,
+		Rd:             isa.IntPtr(int(t.Rd)),
+		ProduceStage:   isa.EX,
+		ConsumeStage:   isa.ID,
+	}
+
+	return inst
+}
+
+func (i *AUIPC) ExecuteOperation(s *isa.State) {
+	s.Latch.Result = s.PC + i.Imm<<12
+}
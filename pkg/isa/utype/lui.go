@@ -0,0 +1,32 @@
+package utype
+
+import isa "riscv-instruction-encoder/pkg/isa"
+
+type LUI struct {
+	Type
+}
+
+func newLUI(t Type) *LUI {
+	inst := &LUI{Type: t}
+
+	inst.InstructionMeta = isa.InstructionMeta{
+		Name:           "LUI",
+		OpCode:         uint32(t.Opcode),
+		IsLoad:         false,
+		IsStore:        false,
+		IsBranch:       false,
+		IsJump:         false,
+		WritesRegister: true,
+		ReadsRegister:  false,
+		Rs:             nil,
+		Rd:             isa.IntPtr(int(t.Rd)),
+		ProduceStage:   isa.EX,
+		ConsumeStage:   isa.ID,
+	}
+
+	return inst
+}
+
+func (i *LUI) ExecuteOperation(s *isa.State) {
+	s.Latch.Result = i.Imm << 12
+}
@@ -5,6 +5,11 @@ import (
 	isa "riscv-instruction-encoder/pkg/isa"
 )
 
+const (
+	OP_LUI   = 0x37
+	OP_AUIPC = 0x17
+)
+
 type Type struct {
 	isa.BaseInstruction
 	Opcode uint8  // 7 bits
@@ -17,10 +22,37 @@ func (u *Type) Decode(inst uint32) isa.Instruction {
 	u.Rd = uint8((inst >> 7) & 0x1F)
 	u.Imm = uint32(inst>>12) & 0xFFFFF
 	u.InstructionMeta = isa.InstructionMeta{}
-	return u
+	return u.findInstruction()
+}
+
+func (u *Type) Encode() uint32 {
+	return u.Imm<<12 | uint32(u.Rd)<<7 | uint32(u.Opcode)
 }
 
 func (u *Type) String() string {
-	return fmt.Sprintf("formato = U {opcode=%02X, rd=%d, imm=%d}",
-		u.Opcode, u.Rd, u.Imm)
+	if u.InstructionMeta.Name == "" {
+		return fmt.Sprintf("formato = U {opcode=%02X, rd=%d, imm=%d}",
+			u.Opcode, u.Rd, u.Imm)
+	}
+	return fmt.Sprintf("%s {opcode=%02X, rd=%d, imm=%d}",
+		u.InstructionMeta.Name, u.Opcode, u.Rd, u.Imm)
+}
+
+func (u *Type) findInstruction() isa.Instruction {
+	switch u.Opcode {
+	case OP_LUI:
+		return newLUI(*u)
+	case OP_AUIPC:
+		return newAUIPC(*u)
+	}
+	return u
+}
+
+// Stages
+func (u *Type) ExecuteOperation(s *isa.State) {
+	s.IllegalInstruction(u.String())
+}
+
+func (u *Type) ExecuteWriteBack(s *isa.State) {
+	s.WriteReg(u.Rd, s.Latch.Result)
 }
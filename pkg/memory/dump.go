@@ -0,0 +1,38 @@
+package memory
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// HexDump writes length bytes starting at addr in the classic 16-bytes per
+// row layout with an ASCII column. The region stops at the top of the
+// address space instead of wrapping around.
+func (m *Memory) HexDump(w io.Writer, addr uint32, length int) {
+	start := uint64(addr &^ 0xF)
+	end := min(uint64(addr)+uint64(length), 1<<32)
+
+	for row := start; row < end; row += 16 {
+		var hex, ascii strings.Builder
+		for i := uint64(0); i < 16; i++ {
+			a := row + i
+			if a < uint64(addr) || a >= end {
+				hex.WriteString("   ")
+				ascii.WriteByte(' ')
+			} else {
+				b := m.Byte(uint32(a))
+				fmt.Fprintf(&hex, "%02x ", b)
+				if b >= 0x20 && b < 0x7F {
+					ascii.WriteByte(b)
+				} else {
+					ascii.WriteByte('.')
+				}
+			}
+			if i == 7 {
+				hex.WriteByte(' ')
+			}
+		}
+		fmt.Fprintf(w, "%08x  %s |%s|\n", row, hex.String(), ascii.String())
+	}
+}
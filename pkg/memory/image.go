@@ -0,0 +1,90 @@
+package memory
+
+import (
+	"bufio"
+	"debug/elf"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// LoadHexImage reads a $readmemh style image: one 32-bit hexadecimal word
+// per line, with "@address" lines moving the load address. Words are stored
+// with the memory's endianness; '#' and "//" start comments.
+func (m *Memory) LoadHexImage(filePath string) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return fmt.Errorf("erro ao abrir imagem: %w", err)
+	}
+	defer file.Close()
+
+	var addr uint32
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		row := scanner.Text()
+		if i := strings.Index(row, "//"); i >= 0 {
+			row = row[:i]
+		}
+		if i := strings.Index(row, "#"); i >= 0 {
+			row = row[:i]
+		}
+
+		for _, field := range strings.Fields(row) {
+			if strings.HasPrefix(field, "@") {
+				v, err := strconv.ParseUint(field[1:], 16, 32)
+				if err != nil {
+					return fmt.Errorf("%s:%d: endereço inválido %q", filePath, line, field)
+				}
+				addr = uint32(v)
+				continue
+			}
+
+			v, err := strconv.ParseUint(field, 16, 32)
+			if err != nil {
+				return fmt.Errorf("%s:%d: palavra inválida %q", filePath, line, field)
+			}
+			m.put(addr, 4, uint32(v))
+			addr += 4
+		}
+	}
+	return scanner.Err()
+}
+
+// LoadELF copies every loadable segment of an ELF file into memory, leaving
+// the part past the file size (.bss) zeroed. It returns the entry point and
+// the first address past the highest segment, where the heap can start.
+func (m *Memory) LoadELF(filePath string) (entry uint32, end uint32, err error) {
+	file, err := elf.Open(filePath)
+	if err != nil {
+		return 0, 0, fmt.Errorf("erro ao abrir ELF: %w", err)
+	}
+	defer file.Close()
+
+	if file.Class != elf.ELFCLASS32 || file.Machine != elf.EM_RISCV {
+		return 0, 0, fmt.Errorf("%s não é um executável RV32", filePath)
+	}
+	if file.Data == elf.ELFDATA2MSB {
+		m.Endian = BigEndian
+	}
+
+	for _, prog := range file.Progs {
+		if prog.Type != elf.PT_LOAD {
+			continue
+		}
+		if top := uint32(prog.Paddr + prog.Memsz); top > end {
+			end = top
+		}
+		if prog.Filesz == 0 {
+			continue
+		}
+		data := make([]byte, prog.Filesz)
+		if _, err := prog.ReadAt(data, 0); err != nil && err != io.EOF {
+			return 0, 0, err
+		}
+		m.LoadImage(uint32(prog.Paddr), data)
+	}
+
+	return uint32(file.Entry), end, nil
+}
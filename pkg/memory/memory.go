@@ -0,0 +1,202 @@
+package memory
+
+import (
+	"fmt"
+)
+
+const (
+	PageBits = 12
+	PageSize = 1 << PageBits
+	pageMask = PageSize - 1
+)
+
+// MisalignedPolicy decides what happens to a half or word access whose
+// address is not a multiple of its size.
+type MisalignedPolicy int
+
+const (
+	// Trap raises an address-misaligned exception, as RV32I hardware may.
+	MisalignedTrap MisalignedPolicy = iota
+	// Split performs the access as individual byte accesses and counts it.
+	MisalignedSplit
+	// Allow performs the access silently.
+	MisalignedAllow
+)
+
+func ParseMisalignedPolicy(name string) (MisalignedPolicy, error) {
+	switch name {
+	case "trap":
+		return MisalignedTrap, nil
+	case "split":
+		return MisalignedSplit, nil
+	case "allow":
+		return MisalignedAllow, nil
+	}
+	return MisalignedTrap, fmt.Errorf("política de desalinhamento inválida: %s (use 'trap', 'split' ou 'allow')", name)
+}
+
+type Endianness int
+
+const (
+	LittleEndian Endianness = iota
+	BigEndian
+)
+
+type MisalignedError struct {
+	Addr  uint32
+	Size  int
+	Store bool
+}
+
+func (e *MisalignedError) Error() string {
+	kind := "leitura"
+	if e.Store {
+		kind = "escrita"
+	}
+	return fmt.Sprintf("%s desalinhada de %d bytes em 0x%08X", kind, e.Size, e.Addr)
+}
+
+type Stats struct {
+	Loads      int
+	Stores     int
+	Misaligned int
+	Split      int
+}
+
+// Memory is a sparse 32-bit address space. Pages are allocated on the first
+// store that touches them; reads of untouched pages return zero.
+type Memory struct {
+	pages      map[uint32]*[PageSize]byte
+	Misaligned MisalignedPolicy
+	Endian     Endianness
+	Stats      Stats
+}
+
+func New() *Memory {
+	return &Memory{pages: make(map[uint32]*[PageSize]byte)}
+}
+
+func (m *Memory) page(addr uint32, allocate bool) *[PageSize]byte {
+	number := addr >> PageBits
+	page := m.pages[number]
+	if page == nil && allocate {
+		page = new([PageSize]byte)
+		m.pages[number] = page
+	}
+	return page
+}
+
+func (m *Memory) Byte(addr uint32) byte {
+	page := m.page(addr, false)
+	if page == nil {
+		return 0
+	}
+	return page[addr&pageMask]
+}
+
+func (m *Memory) SetByte(addr uint32, value byte) {
+	m.page(addr, true)[addr&pageMask] = value
+}
+
+func (m *Memory) checkAlignment(addr uint32, size int, store bool) error {
+	if size == 1 || addr%uint32(size) == 0 {
+		return nil
+	}
+	m.Stats.Misaligned++
+	switch m.Misaligned {
+	case MisalignedTrap:
+		return &MisalignedError{Addr: addr, Size: size, Store: store}
+	case MisalignedSplit:
+		m.Stats.Split++
+	}
+	return nil
+}
+
+func checkSize(size int) error {
+	switch size {
+	case 1, 2, 4:
+		return nil
+	}
+	return fmt.Errorf("tamanho de acesso inválido: %d", size)
+}
+
+// byteIndex gives the position inside the accessed value of byte i.
+func (m *Memory) byteIndex(i, size int) int {
+	if m.Endian == BigEndian {
+		return size - 1 - i
+	}
+	return i
+}
+
+func (m *Memory) Load(addr uint32, size int) (uint32, error) {
+	if err := checkSize(size); err != nil {
+		return 0, err
+	}
+	if err := m.checkAlignment(addr, size, false); err != nil {
+		return 0, err
+	}
+	m.Stats.Loads++
+
+	var value uint32
+	for i := 0; i < size; i++ {
+		value |= uint32(m.Byte(addr+uint32(i))) << (8 * m.byteIndex(i, size))
+	}
+	return value, nil
+}
+
+func (m *Memory) Store(addr uint32, size int, value uint32) error {
+	if err := checkSize(size); err != nil {
+		return err
+	}
+	if err := m.checkAlignment(addr, size, true); err != nil {
+		return err
+	}
+	m.Stats.Stores++
+	m.put(addr, size, value)
+	return nil
+}
+
+// put writes value without alignment checks or statistics, for loaders.
+func (m *Memory) put(addr uint32, size int, value uint32) {
+	for i := 0; i < size; i++ {
+		m.SetByte(addr+uint32(i), byte(value>>(8*m.byteIndex(i, size))))
+	}
+}
+
+// Fetch reads an instruction word. Instruction addresses must always be
+// word aligned and fetches are not counted as data accesses. RISC-V
+// instruction parcels are little-endian whatever the endianness of data.
+func (m *Memory) Fetch(addr uint32) (uint32, error) {
+	if addr%4 != 0 {
+		return 0, &MisalignedError{Addr: addr, Size: 4}
+	}
+	var value uint32
+	for i := 0; i < 4; i++ {
+		value |= uint32(m.Byte(addr+uint32(i))) << (8 * i)
+	}
+	return value, nil
+}
+
+// StoreInstruction writes an instruction word little-endian, the order
+// Fetch reads it in, without counting a data access.
+func (m *Memory) StoreInstruction(addr uint32, value uint32) error {
+	if addr%4 != 0 {
+		return &MisalignedError{Addr: addr, Size: 4, Store: true}
+	}
+	for i := 0; i < 4; i++ {
+		m.SetByte(addr+uint32(i), byte(value>>(8*i)))
+	}
+	return nil
+}
+
+// LoadImage copies data into memory starting at base.
+func (m *Memory) LoadImage(base uint32, data []byte) {
+	for i, b := range data {
+		m.SetByte(base+uint32(i), b)
+	}
+}
+
+// Pages returns the number of pages currently allocated.
+func (m *Memory) Pages() int {
+	return len(m.pages)
+}
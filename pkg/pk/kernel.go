@@ -0,0 +1,231 @@
+// Package pk emulates the subset of the RISC-V proxy kernel (newlib) system
+// call ABI that freestanding test programs rely on: the call number is in
+// a7, arguments in a0-a2 and the result is returned in a0.
+package pk
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"riscv-instruction-encoder/pkg/isa"
+)
+
+// System call numbers of the newlib/pk ABI.
+const (
+	SysClose     = 57
+	SysRead      = 63
+	SysWrite     = 64
+	SysFstat     = 80
+	SysExit      = 93
+	SysExitGroup = 94
+	SysBrk       = 214
+)
+
+const (
+	regA0 = 10
+	regA1 = 11
+	regA2 = 12
+	regA7 = 17
+
+	errBadf   = 9
+	errNosys  = 38
+	errFault  = 14
+	maxBuffer = 1 << 20
+)
+
+// Kernel implements isa.Environment. Zero values of Stdin, Stdout and Stderr
+// fall back to the process's own streams.
+type Kernel struct {
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+
+	// ToHost is the address of the riscv-tests tohost word, polled after
+	// every instruction when HasToHost is set.
+	ToHost    uint32
+	HasToHost bool
+
+	Exited   bool
+	ExitCode int
+	Syscalls int
+
+	brk uint32
+}
+
+// New creates a kernel whose heap starts at heapStart (usually the end of
+// the loaded image).
+func New(heapStart uint32) *Kernel {
+	return &Kernel{brk: heapStart}
+}
+
+func (k *Kernel) stdin() io.Reader {
+	if k.Stdin == nil {
+		return os.Stdin
+	}
+	return k.Stdin
+}
+
+func (k *Kernel) output(fd uint32) io.Writer {
+	switch fd {
+	case 1:
+		if k.Stdout == nil {
+			return os.Stdout
+		}
+		return k.Stdout
+	case 2:
+		if k.Stderr == nil {
+			return os.Stderr
+		}
+		return k.Stderr
+	}
+	return nil
+}
+
+func errno(code int) uint32 {
+	return uint32(-code)
+}
+
+func (k *Kernel) exit(s *isa.State, code uint32) {
+	k.Exited = true
+	k.ExitCode = int(int32(code))
+	s.Halted = true
+}
+
+func (k *Kernel) Ecall(s *isa.State) {
+	k.Syscalls++
+	a0, a1, a2 := s.ReadReg(regA0), s.ReadReg(regA1), s.ReadReg(regA2)
+
+	var ret uint32
+	switch s.ReadReg(regA7) {
+	case SysExit, SysExitGroup:
+		k.exit(s, a0)
+		return
+	case SysWrite:
+		ret = k.write(s, a0, a1, a2)
+	case SysRead:
+		ret = k.read(s, a0, a1, a2)
+	case SysBrk:
+		if a0 != 0 {
+			k.brk = a0
+		}
+		ret = k.brk
+	case SysClose:
+		ret = 0
+	case SysFstat:
+		// newlib only uses fstat to pick a buffering mode; an all-zero
+		// stat buffer is acceptable
+		ret = 0
+	default:
+		ret = errno(errNosys)
+	}
+	s.WriteReg(regA0, ret)
+}
+
+func (k *Kernel) write(s *isa.State, fd, addr, length uint32) uint32 {
+	w := k.output(fd)
+	if w == nil {
+		return errno(errBadf)
+	}
+	if length > maxBuffer {
+		length = maxBuffer
+	}
+
+	buf := make([]byte, length)
+	for i := range buf {
+		b, err := peekByte(s, addr+uint32(i))
+		if err != nil {
+			return errno(errFault)
+		}
+		buf[i] = b
+	}
+	n, err := w.Write(buf)
+	if err != nil {
+		return errno(errBadf)
+	}
+	return uint32(n)
+}
+
+func (k *Kernel) read(s *isa.State, fd, addr, length uint32) uint32 {
+	if fd != 0 {
+		return errno(errBadf)
+	}
+	if length > maxBuffer {
+		length = maxBuffer
+	}
+
+	buf := make([]byte, length)
+	n, err := k.stdin().Read(buf)
+	if err != nil && err != io.EOF {
+		return errno(errBadf)
+	}
+	for i := 0; i < n; i++ {
+		if err := pokeByte(s, addr+uint32(i), buf[i]); err != nil {
+			return errno(errFault)
+		}
+	}
+	return uint32(n)
+}
+
+// peek reads the word at addr without counting a data access when the
+// memory allows it: the kernel's own accesses are not the program's.
+func peek(s *isa.State, addr uint32) (uint32, error) {
+	if m, ok := s.Memory.(interface{ Fetch(uint32) (uint32, error) }); ok {
+		return m.Fetch(addr)
+	}
+	return s.Memory.Load(addr, 4)
+}
+
+func peekByte(s *isa.State, addr uint32) (byte, error) {
+	word, err := peek(s, addr&^3)
+	if err != nil {
+		return 0, err
+	}
+	return byte(word >> (8 * (addr & 3))), nil
+}
+
+// pokeByte writes a byte without counting a data access when the memory
+// allows it.
+func pokeByte(s *isa.State, addr uint32, value byte) error {
+	if m, ok := s.Memory.(interface{ SetByte(uint32, byte) }); ok {
+		m.SetByte(addr, value)
+		return nil
+	}
+	return s.Memory.Store(addr, 1, uint32(value))
+}
+
+// Ebreak stops the program as a breakpoint with no debugger attached.
+func (k *Kernel) Ebreak(s *isa.State) {
+	s.Raise(fmt.Errorf("ebreak em 0x%08X", s.PC))
+}
+
+// Poll implements the riscv-tests host interface: a non-zero word with the
+// low bit set in tohost ends the run with exit code value>>1, so 1 is a pass
+// and anything else names the failing test case.
+func (k *Kernel) Poll(s *isa.State) {
+	if !k.HasToHost || k.Exited {
+		return
+	}
+	value, err := peek(s, k.ToHost)
+	if err != nil || value == 0 {
+		return
+	}
+	if value&1 == 1 {
+		k.exit(s, value>>1)
+	}
+}
+
+// Passed reports whether the program exited with status zero.
+func (k *Kernel) Passed() bool {
+	return k.Exited && k.ExitCode == 0
+}
+
+// Result describes the outcome in riscv-tests terms.
+func (k *Kernel) Result() string {
+	switch {
+	case !k.Exited:
+		return "sem término"
+	case k.ExitCode == 0:
+		return "PASS"
+	}
+	return fmt.Sprintf("FAIL (código %d)", k.ExitCode)
+}
@@ -0,0 +1,195 @@
+// Package predictor implements branch prediction schemes for the pipeline
+// runner. A predictor guesses, at fetch, the address that follows a branch
+// or jump and is trained with the real outcome once it resolves.
+package predictor
+
+import (
+	"fmt"
+	"riscv-instruction-encoder/pkg/isa"
+	"strconv"
+	"strings"
+)
+
+type Predictor interface {
+	Name() string
+	// Predict returns the address to fetch after the control instruction
+	// at pc.
+	Predict(pc uint32, inst isa.Instruction) uint32
+	// Update trains the predictor with the address actually executed next.
+	Update(pc uint32, inst isa.Instruction, nextPC uint32)
+}
+
+// KnowsTargets reports whether p supplies the target of a taken branch at
+// fetch. A predictor without a target buffer only guesses the direction:
+// the fetch unit learns the target when the branch is decoded.
+func KnowsTargets(p Predictor) bool {
+	t, ok := p.(interface{ KnowsTargets() bool })
+	return ok && t.KnowsTargets()
+}
+
+// staticTarget is the destination a decoder can compute for a branch or JAL
+// without reading registers. JALR has none.
+func staticTarget(pc uint32, inst isa.Instruction) (uint32, bool) {
+	target, ok := inst.(isa.BranchTarget)
+	if !ok {
+		return 0, false
+	}
+	return target.Target(pc), true
+}
+
+// isConditional distinguishes branches from unconditional jumps.
+func isConditional(inst isa.Instruction) bool {
+	meta := inst.GetMeta()
+	return meta.IsBranch && !meta.IsJump
+}
+
+// direction turns a taken/not-taken guess into a fetch address. Jumps with
+// a decodable target are always followed.
+func direction(pc uint32, inst isa.Instruction, taken bool) uint32 {
+	target, ok := staticTarget(pc, inst)
+	if !ok {
+		return pc + 4
+	}
+	if !isConditional(inst) || taken {
+		return target
+	}
+	return pc + 4
+}
+
+// NotTaken always fetches the fall-through path.
+type NotTaken struct{}
+
+func (NotTaken) Name() string { return "not-taken" }
+
+func (NotTaken) Predict(pc uint32, inst isa.Instruction) uint32 {
+	if !isConditional(inst) {
+		return direction(pc, inst, true)
+	}
+	return pc + 4
+}
+
+func (NotTaken) Update(pc uint32, inst isa.Instruction, nextPC uint32) {}
+
+// BTFN predicts backward branches (loops) taken and forward ones not taken.
+type BTFN struct{}
+
+func (BTFN) Name() string { return "btfn" }
+
+func (BTFN) Predict(pc uint32, inst isa.Instruction) uint32 {
+	target, ok := staticTarget(pc, inst)
+	return direction(pc, inst, ok && target < pc)
+}
+
+func (BTFN) Update(pc uint32, inst isa.Instruction, nextPC uint32) {}
+
+// BHT is a branch history table of saturating counters indexed by the low
+// PC bits. With 1 bit per entry it repeats the last outcome; with 2 bits a
+// branch must mispredict twice before the prediction flips.
+type BHT struct {
+	bits     int
+	counters []uint8
+}
+
+func NewBHT(bits, size int) *BHT {
+	return &BHT{bits: bits, counters: make([]uint8, size)}
+}
+
+func (b *BHT) Name() string {
+	return fmt.Sprintf("%dbit:%d", b.bits, len(b.counters))
+}
+
+func (b *BHT) index(pc uint32) int {
+	return int((pc >> 2) % uint32(len(b.counters)))
+}
+
+func (b *BHT) Predict(pc uint32, inst isa.Instruction) uint32 {
+	threshold := uint8(1) << (b.bits - 1)
+	return direction(pc, inst, b.counters[b.index(pc)] >= threshold)
+}
+
+func (b *BHT) Update(pc uint32, inst isa.Instruction, nextPC uint32) {
+	if !isConditional(inst) {
+		return
+	}
+	i := b.index(pc)
+	maxCount := uint8(1)<<b.bits - 1
+	if nextPC != pc+4 {
+		if b.counters[i] < maxCount {
+			b.counters[i]++
+		}
+	} else if b.counters[i] > 0 {
+		b.counters[i]--
+	}
+}
+
+type btbEntry struct {
+	valid  bool
+	tag    uint32
+	target uint32
+}
+
+// BTB is a direct-mapped branch target buffer: a hit predicts taken to the
+// stored target, so it also covers JALR, and a miss falls through.
+type BTB struct {
+	entries []btbEntry
+}
+
+func NewBTB(size int) *BTB {
+	return &BTB{entries: make([]btbEntry, size)}
+}
+
+func (b *BTB) Name() string {
+	return fmt.Sprintf("btb:%d", len(b.entries))
+}
+
+func (b *BTB) entry(pc uint32) *btbEntry {
+	return &b.entries[(pc>>2)%uint32(len(b.entries))]
+}
+
+func (b *BTB) Predict(pc uint32, inst isa.Instruction) uint32 {
+	if e := b.entry(pc); e.valid && e.tag == pc {
+		return e.target
+	}
+	return pc + 4
+}
+
+// KnowsTargets is true: a hit redirects fetch in the next cycle.
+func (b *BTB) KnowsTargets() bool { return true }
+
+// Update allocates taken branches and evicts branches that fell through.
+func (b *BTB) Update(pc uint32, inst isa.Instruction, nextPC uint32) {
+	e := b.entry(pc)
+	if nextPC != pc+4 {
+		*e = btbEntry{valid: true, tag: pc, target: nextPC}
+	} else if e.tag == pc {
+		e.valid = false
+	}
+}
+
+// Parse builds a predictor from its command line name: not-taken, btfn,
+// 1bit[:entries], 2bit[:entries] or btb[:entries].
+func Parse(spec string) (Predictor, error) {
+	name, sizeText, hasSize := strings.Cut(strings.ToLower(strings.TrimSpace(spec)), ":")
+	size := 64
+	if hasSize {
+		n, err := strconv.Atoi(sizeText)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("tamanho inválido para o preditor %s: %s", name, sizeText)
+		}
+		size = n
+	}
+
+	switch name {
+	case "not-taken", "nt":
+		return NotTaken{}, nil
+	case "btfn":
+		return BTFN{}, nil
+	case "1bit":
+		return NewBHT(1, size), nil
+	case "2bit":
+		return NewBHT(2, size), nil
+	case "btb":
+		return NewBTB(size), nil
+	}
+	return nil, fmt.Errorf("preditor desconhecido: %s (use not-taken, btfn, 1bit, 2bit ou btb)", spec)
+}
@@ -0,0 +1,127 @@
+package runner
+
+import (
+	"fmt"
+	"riscv-instruction-encoder/pkg/cache"
+	"riscv-instruction-encoder/pkg/isa"
+)
+
+// caches are the instruction and data caches of a run. Misses are never
+// padded with NOPs, since no compiler can predict them: an instruction miss
+// holds fetch until the line arrives and a data miss freezes every stage.
+type caches struct {
+	inst *cache.Cache
+	data *cache.Cache
+	// fetching is the instruction whose line was last looked up and ready
+	// the cycle that line is in the cache.
+	fetching *isa.PipelineInstruction
+	ready    int
+	// freeze is how many more cycles the pipeline waits for a data miss,
+	// and missed the load or store that missed last.
+	freeze   int
+	missed   *isa.PipelineInstruction
+	accessed map[*isa.PipelineInstruction]bool
+	// fetchStalls and dataStalls count the cycles lost to each cache.
+	fetchStalls int
+	dataStalls  int
+	// unknown counts the loads and stores whose address a static program
+	// or a trace does not tell, which skip the data cache.
+	unknown int
+}
+
+func newCaches(inst, data *cache.Config) *caches {
+	c := &caches{accessed: make(map[*isa.PipelineInstruction]bool)}
+	var err error
+	if inst != nil {
+		if c.inst, err = cache.New(*inst); err != nil {
+			fmt.Printf("Cache de instruções desativada: %v\n", err)
+		}
+	}
+	if data != nil {
+		if c.data, err = cache.New(*data); err != nil {
+			fmt.Printf("Cache de dados desativada: %v\n", err)
+		}
+	}
+	return c
+}
+
+// fetchMiss reports whether next is still waiting for its line in the
+// instruction cache, looking the line up the first time next is fetched.
+func (p *Pipeline) fetchMiss(next *isa.PipelineInstruction) bool {
+	c := p.caches
+	if c == nil || c.inst == nil || next == nil {
+		return false
+	}
+	if c.fetching != next {
+		c.fetching = next
+		c.ready = p.CurrentCycle + c.inst.Access(uint32(next.OriginalPC), false)
+	}
+	return p.CurrentCycle < c.ready
+}
+
+// accessData looks up the loads and stores that reached the memory stage
+// this cycle in the data cache; a miss freezes the pipeline from the next
+// cycle on.
+func (p *Pipeline) accessData() {
+	c := p.caches
+	if c == nil || c.data == nil {
+		return
+	}
+	cfg := p.hazardConfig()
+	for _, instr := range p.executingInstructions {
+		meta := instr.Instruction.GetMeta()
+		if instr.Id <= 0 || !(meta.IsLoad || meta.IsStore) || c.accessed[instr] ||
+			cfg.Stage(meta, instr.CurrentStage) != p.topology.Memory {
+			continue
+		}
+		c.accessed[instr] = true
+		if !instr.HasAddress {
+			c.unknown++
+			continue
+		}
+		if stall := c.data.Access(instr.Address, meta.IsStore); stall > 0 {
+			c.freeze += stall
+			c.missed = instr
+		}
+	}
+}
+
+// frozen spends the cycle waiting for a data miss, if one is pending.
+func (p *Pipeline) frozen() bool {
+	c := p.caches
+	if c == nil || c.freeze == 0 {
+		return false
+	}
+	c.freeze--
+	c.dataStalls++
+	p.explainCache(c.missed, categoryDCache)
+	if p.unitTable != nil {
+		p.unitTable.Delay(1)
+	}
+	return true
+}
+
+// retire forgets the data accesses of a finished instruction.
+func (c *caches) retire(instr *isa.PipelineInstruction) {
+	delete(c.accessed, instr)
+}
+
+func (p *Pipeline) printCacheStats() {
+	c := p.caches
+	for _, side := range []struct {
+		name  string
+		cache *cache.Cache
+	}{{"instruções", c.inst}, {"dados", c.data}} {
+		if side.cache == nil {
+			continue
+		}
+		stats := side.cache.Stats()
+		fmt.Printf("Cache de %s: %s\n", side.name, side.cache.Config())
+		fmt.Printf("  acessos %d, acertos %d, faltas %d (acerto %.1f%%), substituições %d, write-backs %d, escritas na memória %d\n",
+			stats.Accesses(), stats.Hits, stats.Misses, stats.HitRate(), stats.Evictions, stats.WriteBacks, stats.WritesThrough)
+	}
+	fmt.Printf("Ciclos de stall por cache: busca %d, dados %d\n", c.fetchStalls, c.dataStalls)
+	if c.unknown > 0 {
+		fmt.Printf("Acessos a dados sem endereço conhecido (use -dynamic): %d\n", c.unknown)
+	}
+}
@@ -0,0 +1,217 @@
+package runner
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"riscv-instruction-encoder/pkg/isa"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Diagram cell markers besides the stage names.
+const (
+	cellStall  = "st"
+	cellBubble = "--"
+	cellFlush  = "xx"
+)
+
+type diagramRow struct {
+	instr  *isa.PipelineInstruction
+	cells  map[int]string
+	issued int
+}
+
+// diagram records which stage each instruction occupies in every cycle, for
+// the classic instructions-by-cycles pipeline table.
+type diagram struct {
+	rows      []*diagramRow
+	index     map[*isa.PipelineInstruction]*diagramRow
+	limit     int
+	lastCycle int
+	stages    []string
+}
+
+func newDiagram(limit int, stages []string) *diagram {
+	return &diagram{index: make(map[*isa.PipelineInstruction]*diagramRow), limit: limit, stages: stages}
+}
+
+// mark sets the cell of instr in cycle. Instructions past the row limit are
+// ignored so long dynamic runs stay readable.
+func (d *diagram) mark(cycle int, instr *isa.PipelineInstruction, cell string) {
+	row := d.index[instr]
+	if row == nil {
+		if d.limit > 0 && len(d.rows) >= d.limit {
+			return
+		}
+		row = &diagramRow{instr: instr, cells: make(map[int]string)}
+		d.rows = append(d.rows, row)
+		d.index[instr] = row
+	}
+	row.cells[cycle] = cell
+	if cell != cellStall && row.issued == 0 {
+		row.issued = cycle
+	}
+	if cycle > d.lastCycle {
+		d.lastCycle = cycle
+	}
+}
+
+// sortRows orders the rows by the cycle each instruction entered the
+// pipeline, so an instruction held back by a hazard appears after the
+// bubbles issued ahead of it.
+func (d *diagram) sortRows() {
+	sort.SliceStable(d.rows, func(i, j int) bool {
+		a, b := d.rows[i].issued, d.rows[j].issued
+		if a == 0 || b == 0 {
+			return b == 0 && a != 0
+		}
+		return a < b
+	})
+}
+
+// record captures the pipeline occupancy at the end of a Step. NOPs are
+// drawn as bubbles and an instruction kept from being fetched as a stall.
+// An instruction frozen by an interlock advances up to decode and repeats
+// it, while the bubble entering execute in its place is drawn from there
+// on.
+func (p *Pipeline) recordDiagram() {
+	if p.diagram == nil {
+		return
+	}
+	for _, instr := range p.executingInstructions {
+		stage := p.hazardConfig().Stage(instr.Instruction.GetMeta(), instr.CurrentStage)
+		if instr == p.held {
+			stage = max(stage, p.heldStage())
+		}
+		cell := p.topology.StageName(stage)
+		switch instr.Id {
+		case nopId:
+			cell = cellBubble
+		case bubbleId:
+			if stage <= p.decodeStage() {
+				continue
+			}
+			cell = cellBubble
+		case wrongPathId:
+			cell = strings.ToLower(cell)
+		}
+		p.diagram.mark(p.CurrentCycle, instr, cell)
+	}
+	if p.pending != nil {
+		cell := cellStall
+		if p.pending == p.held {
+			cell = p.topology.StageName(p.heldStage())
+		}
+		p.diagram.mark(p.CurrentCycle, p.pending, cell)
+	}
+}
+
+// heldStage is where the held instruction is in the current cycle: it goes
+// through fetch from the cycle it was fetched and stays in decode.
+func (p *Pipeline) heldStage() isa.Stage {
+	return min(isa.IF+isa.Stage(p.CurrentCycle-p.heldFrom), p.decodeStage())
+}
+
+func diagramLabel(instr *isa.PipelineInstruction) string {
+	switch instr.Id {
+	case nopId:
+		return "NOP"
+	case bubbleId:
+		return "(bolha)"
+	case wrongPathId:
+		return "(caminho errado)"
+	}
+	return fmt.Sprintf("0x%08X %s", instr.OriginalPC, instr.Instruction.GetMeta().Name)
+}
+
+func (p *Pipeline) writeDiagram() {
+	if p.diagram == nil || p.diagram_path == "" {
+		return
+	}
+	p.diagram.sortRows()
+	if err := p.diagram.writeText(p.diagram_path + ".txt"); err != nil {
+		fmt.Printf("Error to write diagram %s.txt: %v\n", p.diagram_path, err)
+	}
+	if err := p.diagram.writeCSV(p.diagram_path + ".csv"); err != nil {
+		fmt.Printf("Error to write diagram %s.csv: %v\n", p.diagram_path, err)
+	}
+}
+
+func (d *diagram) writeText(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	labelWidth := len("Ciclo")
+	for _, row := range d.rows {
+		labelWidth = max(labelWidth, len(diagramLabel(row.instr)))
+	}
+
+	var b strings.Builder
+	var line strings.Builder
+	fmt.Fprintf(&line, "%-*s", labelWidth, "Ciclo")
+	for cycle := 1; cycle <= d.lastCycle; cycle++ {
+		fmt.Fprintf(&line, " %4d", cycle)
+	}
+	b.WriteString(line.String() + "\n")
+	for _, row := range d.rows {
+		line.Reset()
+		fmt.Fprintf(&line, "%-*s", labelWidth, diagramLabel(row.instr))
+		for cycle := 1; cycle <= d.lastCycle; cycle++ {
+			fmt.Fprintf(&line, " %4s", row.cells[cycle])
+		}
+		b.WriteString(strings.TrimRight(line.String(), " ") + "\n")
+	}
+	// wrong-path instructions never get past the first stages
+	wrongPath := d.stages[:min(3, len(d.stages))]
+	fmt.Fprintf(&b, "\nLegenda: %s = estágio, %s = bolha (NOP), %s = stall, %s = caminho errado, %s = descartada\n",
+		strings.Join(d.stages, " "), cellBubble, cellStall, strings.ToLower(strings.Join(wrongPath, " ")), cellFlush)
+
+	_, err = file.WriteString(b.String())
+	return err
+}
+
+func (d *diagram) writeCSV(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	w := csv.NewWriter(file)
+	header := []string{"id", "pc", "instrucao"}
+	for cycle := 1; cycle <= d.lastCycle; cycle++ {
+		header = append(header, strconv.Itoa(cycle))
+	}
+	if err := w.Write(header); err != nil {
+		return err
+	}
+
+	for _, row := range d.rows {
+		name := "NOP"
+		switch row.instr.Id {
+		case bubbleId:
+			name = "bolha"
+		case wrongPathId:
+			name = "caminho errado"
+		}
+		pc := ""
+		if row.instr.Id > 0 {
+			name = row.instr.Instruction.GetMeta().Name
+			pc = fmt.Sprintf("0x%08X", row.instr.OriginalPC)
+		}
+		record := []string{strconv.Itoa(row.instr.Id), pc, name}
+		for cycle := 1; cycle <= d.lastCycle; cycle++ {
+			record = append(record, row.cells[cycle])
+		}
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
@@ -0,0 +1,175 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"riscv-instruction-encoder/pkg/hazard"
+	"riscv-instruction-encoder/pkg/isa"
+	"sort"
+	"strings"
+)
+
+// Categories of the stalls that are not hazards between instructions.
+const (
+	categoryICache = "falta na cache de instruções"
+	categoryDCache = "falta na cache de dados"
+)
+
+// explanation tells why one NOP or stall cycle was inserted.
+type explanation struct {
+	cycle int
+	// stall is set for a hardware stall cycle, clear for an inserted NOP.
+	stall    bool
+	category string
+	// register is -1 unless the hazard is on a register.
+	register int
+	// dependence names the pair of instructions of a data hazard.
+	dependence string
+	text       string
+}
+
+// instrLabel names an instruction after its mnemonic and address, e.g.
+// ADD@0x18.
+func instrLabel(instr *isa.PipelineInstruction) string {
+	return fmt.Sprintf("%s@0x%X", instr.Instruction.GetMeta().Name, instr.OriginalPC)
+}
+
+// explain records why next waits for h in the current cycle.
+func (p *Pipeline) explain(next *isa.PipelineInstruction, h hazard.Hazard) {
+	if p.explain_path == "" {
+		return
+	}
+	e := explanation{
+		cycle:    p.CurrentCycle,
+		stall:    p.interlock || h.Kind == hazard.KindMemoryPort,
+		category: h.Kind.String(),
+		register: -1,
+	}
+	consumer := instrLabel(next)
+	switch h.Kind {
+	case hazard.KindRAW, hazard.KindLoadUse:
+		e.register = h.Register
+		e.dependence = fmt.Sprintf("%s → %s", instrLabel(h.Producer), consumer)
+		e.text = fmt.Sprintf("%s em x%d de %s para %s", h.Kind, h.Register, instrLabel(h.Producer), consumer)
+	case hazard.KindWAR:
+		e.register = h.Register
+		e.dependence = fmt.Sprintf("%s → %s", instrLabel(h.Producer), consumer)
+		e.text = fmt.Sprintf("WAR em x%d: %s escreveria antes de %s ler", h.Register, consumer, instrLabel(h.Producer))
+	case hazard.KindWAW:
+		e.register = h.Register
+		e.dependence = fmt.Sprintf("%s → %s", instrLabel(h.Producer), consumer)
+		e.text = fmt.Sprintf("WAW em x%d: %s escreveria antes de %s", h.Register, consumer, instrLabel(h.Producer))
+	case hazard.KindControl:
+		e.text = fmt.Sprintf("desvio não resolvido: %s espera %s", consumer, instrLabel(h.Producer))
+	case hazard.KindStructural:
+		e.category = fmt.Sprintf("estrutural em %s", h.Unit)
+		e.text = fmt.Sprintf("estrutural em %s: %s espera uma unidade livre", h.Unit, consumer)
+	case hazard.KindMemoryPort:
+		e.text = fmt.Sprintf("porta de memória ocupada por %s na busca de %s", instrLabel(h.Producer), consumer)
+	}
+	p.explanations = append(p.explanations, e)
+}
+
+// explainCache records a cycle lost to a miss of instr in a cache.
+func (p *Pipeline) explainCache(instr *isa.PipelineInstruction, category string) {
+	if p.explain_path == "" {
+		return
+	}
+	p.explanations = append(p.explanations, explanation{
+		cycle:    p.CurrentCycle,
+		stall:    true,
+		category: category,
+		register: -1,
+		text:     fmt.Sprintf("%s: %s", category, instrLabel(instr)),
+	})
+}
+
+// counted is one line of a tally, kept in order of first appearance so ties
+// are reported in program order.
+type counted struct {
+	name  string
+	count int
+}
+
+func tally(entries []explanation, key func(explanation) (string, bool)) []counted {
+	var counts []counted
+	index := make(map[string]int)
+	for _, e := range entries {
+		name, ok := key(e)
+		if !ok {
+			continue
+		}
+		if i, seen := index[name]; seen {
+			counts[i].count++
+			continue
+		}
+		index[name] = len(counts)
+		counts = append(counts, counted{name, 1})
+	}
+	sort.SliceStable(counts, func(i, j int) bool { return counts[i].count > counts[j].count })
+	return counts
+}
+
+func (p *Pipeline) categoryCounts() []counted {
+	return tally(p.explanations, func(e explanation) (string, bool) { return e.category, true })
+}
+
+func formatCounts(counts []counted) string {
+	var parts []string
+	for _, c := range counts {
+		parts = append(parts, fmt.Sprintf("%s %d", c.name, c.count))
+	}
+	if len(parts) == 0 {
+		return "nenhum"
+	}
+	return strings.Join(parts, ", ")
+}
+
+func (p *Pipeline) printExplanationStats() {
+	fmt.Printf("Explicações: %s\n", p.explain_path+".txt")
+	fmt.Printf("Stalls por categoria: %s\n", formatCounts(p.categoryCounts()))
+}
+
+// writeExplanations writes one line per NOP or stall cycle with its cause,
+// followed by the counts per category, per register and per dependence.
+func (p *Pipeline) writeExplanations() {
+	if p.explain_path == "" {
+		return
+	}
+	path := p.explain_path + ".txt"
+	file, err := os.Create(path)
+	if err != nil {
+		fmt.Printf("Error to create file %s: %v\n", path, err)
+		return
+	}
+	defer file.Close()
+
+	var b strings.Builder
+	b.WriteString("Ciclo\tAção\tMotivo\n")
+	b.WriteString("===============================\n")
+	for _, e := range p.explanations {
+		what := "NOP"
+		if e.stall {
+			what = "stall"
+		}
+		fmt.Fprintf(&b, "%d\t%s\t%s\n", e.cycle, what, e.text)
+	}
+
+	registers := tally(p.explanations, func(e explanation) (string, bool) {
+		return fmt.Sprintf("x%d", e.register), e.register >= 0
+	})
+	dependences := tally(p.explanations, func(e explanation) (string, bool) {
+		return e.dependence, e.dependence != ""
+	})
+	fmt.Fprintf(&b, "\nTotal: %d\n", len(p.explanations))
+	fmt.Fprintf(&b, "Por categoria: %s\n", formatCounts(p.categoryCounts()))
+	fmt.Fprintf(&b, "Por registrador: %s\n", formatCounts(registers))
+	b.WriteString("Por dependência:\n")
+	for _, d := range dependences {
+		fmt.Fprintf(&b, "  %s: %d\n", d.name, d.count)
+	}
+
+	if _, err := file.WriteString(b.String()); err != nil {
+		fmt.Printf("Error to write in file %s: %v\n", path, err)
+	}
+}
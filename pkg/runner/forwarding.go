@@ -0,0 +1,76 @@
+package runner
+
+import (
+	"fmt"
+	"riscv-instruction-encoder/pkg/hazard"
+	"riscv-instruction-encoder/pkg/isa"
+	"strings"
+)
+
+// recordSources attributes every operand next reads from an instruction
+// in flight to the forwarding path, or the register file, that delivers it.
+// Only the youngest writer of a register matters, as its value is the one
+// read.
+func (p *Pipeline) recordSources(next *isa.PipelineInstruction) {
+	if p.paths == nil || !p.forwarding || !p.data_hazard || next.Id <= 0 {
+		return
+	}
+	cfg := p.hazardConfig()
+	for operand, rs := range next.Instruction.GetMeta().Rs {
+		if rs == 0 {
+			continue
+		}
+		for i := len(p.executingInstructions) - 1; i >= 0; i-- {
+			prev := p.executingInstructions[i]
+			if rd, ok := prev.Instruction.GetMeta().Dest(); !ok || rd != rs || prev.HasCompleted {
+				continue
+			}
+			if path, ok := hazard.OperandSource(*next, *prev, operand, cfg); ok {
+				p.pathUses[path]++
+			}
+			break
+		}
+	}
+}
+
+// recordSavings counts, for every missing path, whether adding it alone
+// would have spared the data stall of next.
+func (p *Pipeline) recordSavings(next *isa.PipelineInstruction) {
+	if p.paths == nil || !p.forwarding {
+		return
+	}
+	for path := hazard.Path(0); path < hazard.NumPaths; path++ {
+		if p.paths[path] {
+			continue
+		}
+		paths := *p.paths
+		paths[path] = true
+		cfg := p.hazardConfig()
+		cfg.Paths = &paths
+		if !hazard.HasDataHazard(*next, p.executingInstructions, cfg) {
+			p.pathSavings[path]++
+		}
+	}
+}
+
+func (p *Pipeline) printPathStats() {
+	cfg := p.hazardConfig()
+	var enabled, uses, savings []string
+	for path := hazard.Path(0); path < hazard.NumPaths; path++ {
+		if p.paths[path] {
+			enabled = append(enabled, cfg.PathName(path))
+			uses = append(uses, fmt.Sprintf("%s %d", cfg.PathName(path), p.pathUses[path]))
+		} else {
+			savings = append(savings, fmt.Sprintf("%s %d", cfg.PathName(path), p.pathSavings[path]))
+		}
+	}
+	uses = append(uses, fmt.Sprintf("%s %d", cfg.PathName(hazard.RegisterFile), p.pathUses[hazard.RegisterFile]))
+	if len(enabled) == 0 {
+		enabled = append(enabled, "nenhum")
+	}
+	fmt.Printf("Caminhos de forwarding: %s\n", strings.Join(enabled, ", "))
+	fmt.Printf("Dependências servidas: %s\n", strings.Join(uses, ", "))
+	if len(savings) > 0 {
+		fmt.Printf("Stalls evitáveis por caminho ausente: %s\n", strings.Join(savings, ", "))
+	}
+}
@@ -3,6 +3,8 @@ package runner
 import (
 	"fmt"
 	"os"
+	"riscv-instruction-encoder/pkg/isa"
+	"strings"
 )
 
 func (p *Pipeline) writeFile() {
@@ -15,7 +17,19 @@ func (p *Pipeline) writeFile() {
 	_, _ = file.WriteString("PC\tInstruction\n")
 	_, _ = file.WriteString("===============================\n")
 	for _, instr := range p.Instructions {
-		line := fmt.Sprintf("0x%08X\t%s\n", instr.OriginalPC, instr.Instruction.String())
+		pc := uint32(instr.OriginalPC)
+		if p.relocated {
+			pc = uint32(instr.PC)
+		}
+		var annotation string
+		if instr.Id > 0 {
+			for _, label := range p.symbols.Labels(pc) {
+				_, _ = file.WriteString(label + ":\n")
+			}
+			annotation = p.symbols.Annotate(pc, instr.Instruction)
+		}
+
+		line := fmt.Sprintf("0x%08X\t%s%s\n", pc, instr.Instruction.String(), annotation)
 		_, err := file.WriteString(line)
 		if err != nil {
 			fmt.Printf("Error to write in file %s: %v\n", p.file_path, err)
@@ -24,20 +38,40 @@ func (p *Pipeline) writeFile() {
 	}
 }
 
-func (p *Pipeline) printResult() {
-	countNop := 0
+// NOPs counts the NOPs inserted into the program.
+func (p *Pipeline) NOPs() int {
+	count := 0
 	for _, instruction := range p.Instructions {
 		if instruction.Instruction.GetMeta().Name == "NOP" {
-			countNop++
+			count++
 		}
 	}
+	return count
+}
+
+// Overhead is the growth of the program caused by the inserted NOPs, in
+// percent of the original instructions.
+func (p *Pipeline) Overhead() float64 {
+	countNop := p.NOPs()
+	return float64(countNop) / float64(len(p.Instructions)-countNop) * 100
+}
+
+func (p *Pipeline) printResult() {
+	countNop := p.NOPs()
 
 	origCount := len(p.Instructions) - countNop
 	totalCount := len(p.Instructions)
-	overhead := float64(totalCount-origCount) / float64(origCount) * 100
+	overhead := p.Overhead()
 
 	fmt.Printf("\nInput: fib_rec_binario.txt (%d instruções)\n", origCount)
-	fmt.Println("Model pipeline: IF ID EX MEM WB")
+	fmt.Printf("Model pipeline: %s\n", p.topology)
+	if p.control_hazard {
+		resolve := p.resolveStage
+		if resolve == 0 {
+			resolve = p.topology.Resolve
+		}
+		fmt.Printf("Resolução de desvios: %s\n", p.topology.StageName(resolve))
+	}
 	fmt.Println()
 
 	var mode string
@@ -55,12 +89,100 @@ func (p *Pipeline) printResult() {
 	if p.forwarding {
 		forwardingText = "com forwarding"
 	}
+	if p.interlock {
+		forwardingText += ", interlock em hardware"
+	}
 
 	fmt.Printf("%s (%s)\n", mode, forwardingText)
 	fmt.Printf("Output: %s\n", p.file_path)
+	if tr, ok := p.source.(*traceSource); ok {
+		fmt.Printf("Fluxo de controle: trace (%d instruções registradas)\n", len(tr.records))
+		if tr.err != nil {
+			fmt.Printf("Trace interrompido: %v\n", tr.err)
+		}
+	}
+	if dyn, ok := p.source.(*dynamicSource); ok {
+		fmt.Println("Fluxo de controle: dinâmico (execução real)")
+		if dyn.hitLimit {
+			fmt.Printf("Execução truncada em %d instruções\n", dyn.limit)
+		}
+		if dyn.err != nil {
+			fmt.Printf("Execução interrompida: %v\n", dyn.err)
+		}
+	}
 	fmt.Printf("Instruções originais: %d\n", origCount)
 	fmt.Printf("Instruções finais: %d\n", totalCount)
-	fmt.Printf("NOPs inseridos: %d\n", countNop)
-	fmt.Printf("Sobreacusto: +%.1f%%\n", overhead)
+	if p.interlock {
+		fmt.Printf("Ciclos de stall: %d\n", p.StallCycles)
+	} else {
+		fmt.Printf("NOPs inseridos: %d\n", countNop)
+		fmt.Printf("Sobreacusto: +%.1f%%\n", overhead)
+		if p.StallCycles > 0 {
+			fmt.Printf("Ciclos de stall (porta de memória): %d\n", p.StallCycles)
+		}
+	}
+	if p.unitTable != nil || p.unifiedMemory {
+		p.printStructuralStats()
+	}
+	if p.paths != nil && p.forwarding && p.data_hazard {
+		p.printPathStats()
+	}
+	if p.caches != nil {
+		p.printCacheStats()
+	}
+	if p.explain_path != "" {
+		p.printExplanationStats()
+	}
+	if p.speculation != nil {
+		stats := p.speculation.stats
+		fmt.Printf("Preditor: %s\n", p.speculation.predictor.Name())
+		fmt.Printf("Previsões: %d (acertos %d, erros %d, acurácia %.1f%%)\n",
+			stats.Predictions, stats.Correct, stats.Mispredictions, stats.Accuracy())
+		fmt.Printf("Ciclos de penalidade: %d (%d instruções descartadas)\n", stats.PenaltyCycles, stats.FlushedInstrs)
+		if stats.Redirects > 0 {
+			fmt.Printf("Desvios tomados redirecionados em decode: %d\n", stats.Redirects)
+		}
+	}
+	if p.issueWidth > 1 {
+		p.printIssueStats()
+	}
+	fmt.Printf("Ciclos: %d\n", p.CurrentCycle)
+	if origCount > 0 {
+		fmt.Printf("CPI: %.2f\n", float64(p.CurrentCycle)/float64(origCount))
+	}
+	if p.issueWidth > 1 && p.CurrentCycle > 0 {
+		fmt.Printf("IPC: %.2f\n", float64(origCount)/float64(p.CurrentCycle))
+	}
 	fmt.Println("========================================")
 }
+
+// PortConflicts counts the cycles fetch lost the unified memory port to a
+// load or store.
+func (p *Pipeline) PortConflicts() int {
+	return p.stalls[causeMemoryPort]
+}
+
+// printStructuralStats breaks the stalls down by cause and the structural
+// ones by the unit that was busy.
+func (p *Pipeline) printStructuralStats() {
+	if p.unitTable != nil {
+		fmt.Printf("Unidades funcionais: %s\n", p.units)
+	}
+	if p.unifiedMemory {
+		fmt.Printf("Memória unificada: uma porta para busca e dados (estágio %s)\n", p.topology.StageName(p.topology.Memory))
+	}
+	var causes []string
+	for why := causeData; why < numCauses; why++ {
+		causes = append(causes, fmt.Sprintf("%s %d", causeNames[why], p.stalls[why]))
+	}
+	fmt.Printf("Stalls por causa: %s\n", strings.Join(causes, ", "))
+	var busy []string
+	for unit, count := range p.structural {
+		if count > 0 {
+			busy = append(busy, fmt.Sprintf("%s %d", isa.Unit(unit), count))
+		}
+	}
+	if len(busy) > 0 {
+		fmt.Printf("Hazards estruturais por unidade: %s\n", strings.Join(busy, ", "))
+	}
+}
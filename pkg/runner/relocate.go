@@ -0,0 +1,95 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"riscv-instruction-encoder/pkg/isa"
+)
+
+// relocate lays the padded static program out at its new addresses: every
+// branch and jump is re-encoded to reach the instruction its target moved
+// to, and the symbols follow their instructions. Streams from an execution
+// or a trace have no layout of their own and keep their original PCs.
+//
+// An address computed from the PC by AUIPC and the instructions that use
+// it (la, call, tail) would need both patched, so a program with AUIPC is
+// not relocated and no padded program is written for it. Absolute code
+// addresses built with LUI and ADDI cannot be told apart from constants and
+// are left as they are.
+func (p *Pipeline) relocate() error {
+	static, ok := p.source.(*staticSource)
+	if !ok {
+		return nil
+	}
+	for _, instr := range static.program {
+		if instr.Instruction != nil && instr.Instruction.GetMeta().Name == "AUIPC" {
+			return fmt.Errorf("AUIPC em 0x%X calcula um endereço relativo ao PC que não é relocado", instr.OriginalPC)
+		}
+	}
+
+	moved := make(map[uint32]uint32, len(static.program)+1)
+	for _, instr := range p.Instructions {
+		if instr.Id > 0 {
+			moved[uint32(instr.OriginalPC)] = uint32(instr.PC)
+		}
+	}
+	moved[uint32(len(static.program)*4)] = uint32(len(p.Instructions) * 4)
+	move := func(addr uint32) uint32 {
+		if to, ok := moved[addr]; ok {
+			return to
+		}
+		// outside the program, the absolute address stays
+		return addr
+	}
+
+	// nothing changes unless every branch still reaches its target
+	retargeted := make(map[*isa.PipelineInstruction]isa.Instruction)
+	for _, instr := range p.Instructions {
+		rel, ok := instr.Instruction.(isa.Relocatable)
+		if !ok || instr.Id <= 0 {
+			continue
+		}
+		target := move(rel.Target(uint32(instr.OriginalPC)))
+		inst, err := rel.Retarget(uint32(instr.PC), target)
+		if err != nil {
+			return err
+		}
+		retargeted[instr] = inst
+	}
+	for instr, inst := range retargeted {
+		instr.Instruction = inst
+	}
+	p.symbols = p.symbols.Relocate(move)
+	p.relocated = true
+	return nil
+}
+
+// writeProgram writes the padded program as instruction words, one per
+// line, in the hex and binary formats the resolver reads.
+func (p *Pipeline) writeProgram() {
+	if p.program_path == "" || !p.relocated {
+		return
+	}
+	formats := []struct {
+		suffix string
+		format string
+	}{
+		{"_hex.txt", "%08x\n"},
+		{"_bin.txt", "%032b\n"},
+	}
+	for _, f := range formats {
+		path := p.program_path + f.suffix
+		file, err := os.Create(path)
+		if err != nil {
+			fmt.Printf("Error to create file %s: %v\n", path, err)
+			continue
+		}
+		for _, instr := range p.Instructions {
+			if _, err := fmt.Fprintf(file, f.format, instr.Instruction.Encode()); err != nil {
+				fmt.Printf("Error to write in file %s: %v\n", path, err)
+				break
+			}
+		}
+		file.Close()
+	}
+}
@@ -1,19 +1,120 @@
 package runner
 
 import (
+	"fmt"
+	"riscv-instruction-encoder/pkg/cache"
 	"riscv-instruction-encoder/pkg/hazard"
 	"riscv-instruction-encoder/pkg/isa"
+	"riscv-instruction-encoder/pkg/predictor"
+	"riscv-instruction-encoder/pkg/sim"
+	"riscv-instruction-encoder/pkg/symbols"
+	"riscv-instruction-encoder/pkg/trace"
 )
 
+// Config selects the hazard handling of one pipeline run and where its
+// result is written.
+type Config struct {
+	Forwarding bool
+	// ForwardingPaths, when set with Forwarding, limits forwarding to the
+	// listed paths and reports how often each one served a dependency.
+	ForwardingPaths *hazard.Paths
+	DataHazard      bool
+	ControlHazard   bool
+	// Interlock makes the hazard unit stall the instruction in the front of
+	// the pipeline instead of inserting NOPs into the program.
+	Interlock bool
+	// Predictor, when set, replaces control-hazard stalls with speculative
+	// fetch down the predicted path and a flush on misprediction. It needs
+	// the real control flow of Machine or Trace: a static program always
+	// falls through.
+	Predictor predictor.Predictor
+	// BranchResolveStage is where branches and jumps resolve; zero keeps
+	// the default of the topology.
+	BranchResolveStage isa.Stage
+	// Topology is the pipeline modelled; nil means isa.FiveStage.
+	Topology *isa.Topology
+	// Issue sets how many instructions may enter the pipeline per cycle;
+	// a zero width is the scalar pipeline.
+	Issue IssueConfig
+	// Units, when set, models the functional units of the execute stage:
+	// their latencies, and structural hazards when they are all busy.
+	Units *hazard.Units
+	// UnifiedMemory shares one memory port between fetch and the loads and
+	// stores in the memory stage, which then hold fetch back.
+	UnifiedMemory bool
+	// ICache and DCache, when set, put caches in front of fetch and of the
+	// memory stage; their misses stall the pipeline.
+	ICache *cache.Config
+	DCache *cache.Config
+	// DelaySlots is the number of architectural delay slots after every
+	// branch and jump. The program must already have its slots filled (see
+	// package delayslot); they issue without waiting for the branch.
+	DelaySlots int
+	FilePath   string
+	Symbols    *symbols.Table
+	// Machine, when set, makes the pipeline fetch the dynamic instruction
+	// stream executed by the simulator instead of the static program order.
+	// The machine must already hold the program and is consumed by the run.
+	Machine *sim.Machine
+	// MaxInstructions bounds the dynamic stream; zero means no bound.
+	MaxInstructions int
+	// DiagramPath, when set, is the base name (without extension) of the
+	// text and CSV pipeline diagrams written after the run.
+	DiagramPath string
+	// ExplainPath, when set, is the base name of the report that tells why
+	// every NOP or stall cycle was inserted.
+	ExplainPath string
+	// DiagramRows limits the diagram to the first instructions; zero keeps
+	// every row.
+	DiagramRows int
+	// ProgramPath, when set, is the base name of the hex and binary files
+	// the padded program is written to. Only a static program is written.
+	ProgramPath string
+	// Trace, when set, replaces the program with a recorded stream of
+	// retired instructions. It takes precedence over Machine.
+	Trace []trace.Record
+}
+
 type Pipeline struct {
 	CurrentCycle          int
 	Instructions          []*isa.PipelineInstruction
 	NumStages             int
 	executingInstructions []*isa.PipelineInstruction
+	source                source
+	pending               *isa.PipelineInstruction
+	held                  *isa.PipelineInstruction
+	heldFrom              int
 	forwarding            bool
+	paths                 *hazard.Paths
+	pathUses              [hazard.NumPaths + 1]int
+	pathSavings           [hazard.NumPaths]int
+	resolveStage          isa.Stage
+	topology              *isa.Topology
+	issueWidth            int
+	pairing               IssueConfig
+	bundle                []*isa.PipelineInstruction
+	slots                 []SlotStats
+	units                 *hazard.Units
+	unitTable             *hazard.UnitTable
+	unifiedMemory         bool
+	caches                *caches
+	stalls                [numCauses]int
+	structural            [isa.NumUnits]int
+	interlock             bool
+	delaySlots            int
+	slotsLeft             int
+	StallCycles           int
+	speculation           *speculation
 	data_hazard           bool
 	control_hazard        bool
 	file_path             string
+	diagram_path          string
+	explain_path          string
+	explanations          []explanation
+	program_path          string
+	relocated             bool
+	diagram               *diagram
+	symbols               *symbols.Table
 }
 
 func InstructionsToPipeline(instructions []isa.Instruction) []*isa.PipelineInstruction {
@@ -27,119 +128,316 @@ func InstructionsToPipeline(instructions []isa.Instruction) []*isa.PipelineInstr
 			Id:           i + 1,
 			PC:           i * 4,
 			OriginalPC:   i * 4,
+			NextPC:       i*4 + 4,
 		}
 	}
 	return pipelineInstructions
 }
 
-func NewPipeline(instructions []isa.Instruction, forwarding bool, data_hazard bool, control_hazard bool, file_path string) *Pipeline {
-	stages := len(isa.Stages)
+func NewPipeline(instructions []isa.Instruction, cfg Config) *Pipeline {
+	topology := cfg.Topology
+	if topology == nil {
+		topology = isa.FiveStage
+	}
+
+	var src source
+	if cfg.Trace != nil {
+		src = &traceSource{records: cfg.Trace}
+	} else if cfg.Machine != nil {
+		src = &dynamicSource{machine: cfg.Machine, limit: cfg.MaxInstructions}
+	} else {
+		src = &staticSource{program: InstructionsToPipeline(instructions)}
+	}
 
-	return &Pipeline{
+	p := &Pipeline{
 		CurrentCycle:   0,
-		Instructions:   InstructionsToPipeline(instructions),
-		NumStages:      stages,
-		forwarding:     forwarding,
-		data_hazard:    data_hazard,
-		control_hazard: control_hazard,
-		file_path:      file_path,
+		NumStages:      topology.Len(),
+		source:         src,
+		forwarding:     cfg.Forwarding,
+		paths:          cfg.ForwardingPaths,
+		resolveStage:   cfg.BranchResolveStage,
+		topology:       topology,
+		interlock:      cfg.Interlock,
+		delaySlots:     cfg.DelaySlots,
+		data_hazard:    cfg.DataHazard,
+		control_hazard: cfg.ControlHazard,
+		file_path:      cfg.FilePath,
+		diagram_path:   cfg.DiagramPath,
+		explain_path:   cfg.ExplainPath,
+		program_path:   cfg.ProgramPath,
+		unifiedMemory:  cfg.UnifiedMemory,
+		symbols:        cfg.Symbols,
+	}
+	if cfg.Issue.Width > 1 {
+		p.issueWidth = cfg.Issue.Width
+		p.pairing = cfg.Issue
+		p.slots = make([]SlotStats, cfg.Issue.Width)
+	}
+	if cfg.Units != nil {
+		p.units = cfg.Units
+		p.unitTable = hazard.NewUnitTable(*cfg.Units)
 	}
+	if cfg.ICache != nil || cfg.DCache != nil {
+		p.caches = newCaches(cfg.ICache, cfg.DCache)
+	}
+	if cfg.DiagramPath != "" {
+		p.diagram = newDiagram(cfg.DiagramRows, topology.Stages)
+	}
+	if cfg.Predictor != nil && cfg.ControlHazard {
+		p.speculation = &speculation{predictor: cfg.Predictor}
+	}
+	return p
 }
 
-func (p *Pipeline) hasCompleted() bool {
-	for _, instr := range p.Instructions {
-		if !instr.HasCompleted {
-			return false
-		}
+// fetch makes sure an instruction is waiting to be issued, pulling the next
+// one from the source when needed.
+func (p *Pipeline) fetch() *isa.PipelineInstruction {
+	if p.pending == nil {
+		p.pending = p.source.next()
 	}
-	return true
+	return p.pending
 }
 
-func (p *Pipeline) getNextInstruction() (*isa.PipelineInstruction, int) {
-	for i, instruction := range p.Instructions {
-		if !instruction.HasStarted && !instruction.HasCompleted {
-			return instruction, i
-		}
-	}
-	return nil, -1
+func (p *Pipeline) hasCompleted() bool {
+	return p.fetch() == nil && len(p.executingInstructions) == 0
 }
 
+// Ids of the pipeline slots that are not program instructions.
+const (
+	nopId       = -1
+	wrongPathId = -2
+	bubbleId    = -3
+)
+
 func createNOP() *isa.PipelineInstruction {
 	return &isa.PipelineInstruction{
 		Instruction:  isa.NewNOP(),
 		CurrentStage: 1,
 		HasStarted:   true,
 		HasCompleted: false,
-		Id:           -1,
+		Id:           nopId,
 	}
 }
 
-func (p *Pipeline) insertNOPAt(index int) {
+// insertNOP issues a NOP in place of the waiting instruction, which is
+// retried next cycle.
+func (p *Pipeline) insertNOP() {
 	nop := createNOP()
-	if index < len(p.Instructions) {
-		nop.PC = p.Instructions[index].PC
-	} else {
-		nop.PC = len(p.Instructions) * 4
-	}
+	nop.PC = len(p.Instructions) * 4
 
-	p.Instructions = append(
-		p.Instructions[:index],
-		append([]*isa.PipelineInstruction{nop}, p.Instructions[index:]...)...,
-	)
+	p.Instructions = append(p.Instructions, nop)
 	p.executingInstructions = append(p.executingInstructions, nop)
-	for i := index + 1; i < len(p.Instructions); i++ {
-		p.Instructions[i].PC += 4
+}
+
+// hold freezes next in decode for one more cycle while a bubble enters
+// execute in its place. The bubble flows like a NOP issued this cycle but
+// is not part of the program. heldFrom keeps the cycle next was fetched.
+func (p *Pipeline) hold(next *isa.PipelineInstruction) {
+	if p.held != next {
+		p.held = next
+		p.heldFrom = p.CurrentCycle
 	}
+	bubble := createNOP()
+	bubble.Id = bubbleId
+	p.executingInstructions = append(p.executingInstructions, bubble)
+}
+
+// decodeStage is where an interlock freezes an instruction: the stage
+// before execute.
+func (p *Pipeline) decodeStage() isa.Stage {
+	return max(p.topology.Execute-1, isa.IF)
+}
+
+// stall resolves a hazard for one cycle: in hardware the front of the
+// pipeline is frozen while a bubble flows on; in software a NOP is issued.
+// A fetch that loses the memory port is always a hardware stall, since a
+// NOP would need the port as well. Data and structural hazards are found
+// in decode, so the instruction has been fetched and is held there; the
+// others keep it from being fetched.
+func (p *Pipeline) stall(next *isa.PipelineInstruction, why cause, h hazard.Hazard) {
+	p.stalls[why]++
+	p.explain(next, h)
+	if why == causeData {
+		p.recordSavings(next)
+	}
+	if why == causeStructural {
+		p.structural[isa.UnitOf(next.Instruction.GetMeta())]++
+	}
+	if p.interlock || why == causeMemoryPort {
+		p.StallCycles++
+		if p.interlock && (why == causeData || why == causeStructural || p.held == next) {
+			p.hold(next)
+		}
+		return
+	}
+	p.insertNOP()
 }
 
 func (p *Pipeline) insertInstruction(instruction *isa.PipelineInstruction) {
 	instruction.HasStarted = true
 	instruction.CurrentStage = int(isa.IF)
+	instruction.PC = len(p.Instructions) * 4
+	p.Instructions = append(p.Instructions, instruction)
 	p.executingInstructions = append(p.executingInstructions, instruction)
 }
 
+func (p *Pipeline) hazardConfig() hazard.Config {
+	_, static := p.source.(*staticSource)
+	return hazard.Config{
+		Forwarding:    p.forwarding,
+		Paths:         p.paths,
+		ResolveStage:  p.resolveStage,
+		Topology:      p.topology,
+		Units:         p.units,
+		UnifiedMemory: p.unifiedMemory,
+		StaticProgram: static,
+	}
+}
+
+// cause is why an instruction waits before entering the pipeline.
+type cause int
+
+const (
+	causeNone cause = iota
+	causeData
+	causeControl
+	causeStructural
+	causeMemoryPort
+	numCauses
+)
+
+var causeNames = [numCauses]string{"nenhuma", "dados", "controle", "estrutural", "porta de memória"}
+
+// hazardCause returns the hazard next has to wait for, if any. A fetch
+// that loses the shared memory port comes first, since nothing, not even a
+// NOP, can be fetched in that cycle; then data, control and structural
+// hazards in that order.
+func (p *Pipeline) hazardCause(next *isa.PipelineInstruction) (cause, hazard.Hazard) {
+	cfg := p.hazardConfig()
+	if next != p.held {
+		if h, ok := hazard.ExplainMemoryPortHazard(*next, p.executingInstructions, cfg); ok {
+			return causeMemoryPort, h
+		}
+	}
+	if p.data_hazard {
+		if h, ok := hazard.ExplainDataHazard(*next, p.executingInstructions, cfg); ok {
+			return causeData, h
+		}
+	}
+	if p.control_hazard && p.speculation == nil && p.slotsLeft == 0 {
+		if h, ok := hazard.ExplainControlHazard(*next, p.executingInstructions, cfg); ok {
+			return causeControl, h
+		}
+	}
+	if p.unitTable != nil {
+		if h, ok := p.unitTable.ExplainStructuralHazard(*next, p.CurrentCycle, cfg); ok {
+			return causeStructural, h
+		}
+	}
+	return causeNone, hazard.Hazard{}
+}
+
+// hasHazard reports whether next has to wait for the instructions in
+// flight.
+func (p *Pipeline) hasHazard(next *isa.PipelineInstruction) bool {
+	why, _ := p.hazardCause(next)
+	return why != causeNone
+}
+
+// issue sends the waiting instruction into the pipeline.
+func (p *Pipeline) issue(next *isa.PipelineInstruction) {
+	p.recordSources(next)
+	p.insertInstruction(next)
+	p.pending = nil
+	p.bundle = append(p.bundle, next)
+	if p.unitTable != nil {
+		p.unitTable.Reserve(*next, p.CurrentCycle, p.hazardConfig())
+	}
+	if p.slotsLeft > 0 {
+		p.slotsLeft--
+	} else if isControl(next) {
+		p.slotsLeft = p.delaySlots
+	}
+	if p.speculation != nil && isControl(next) {
+		p.speculation.predict(next)
+	}
+}
+
 func (p *Pipeline) Step() {
+	p.bundle = p.bundle[:0]
+	if p.frozen() {
+		p.recordDiagram()
+		return
+	}
+	// once issued, the held instruction catches up with decode in the
+	// deeper front ends
+	if p.held != nil && p.held != p.pending && isa.Stage(p.held.CurrentStage) >= p.decodeStage() {
+		p.held = nil
+	}
+
 	for _, instruction := range p.executingInstructions {
 		instruction.CurrentStage++
 
-		if instruction.CurrentStage >= p.NumStages {
+		// a long-latency unit holds the instruction in the execute stage
+		// for its extra cycles
+		if instruction.CurrentStage >= p.NumStages+p.hazardConfig().ExtraCycles(instruction.Instruction.GetMeta()) {
 			instruction.HasCompleted = true
 		}
 	}
 
-	nextInstruction, index := p.getNextInstruction()
+	if p.speculation != nil {
+		p.speculation.resolve(p)
+	}
+	p.accessData()
+
+	nextInstruction := p.fetch()
 
 	if nextInstruction != nil {
 		nextInstruction.CurrentStage = int(isa.IF)
-		if (hazard.HasDataHazard(*nextInstruction, p.executingInstructions, p.forwarding) && p.data_hazard) || (hazard.HasControlHazard(*nextInstruction, p.executingInstructions, p.forwarding) && p.control_hazard) {
-			p.insertNOPAt(index)
+		if p.speculation != nil && p.speculation.fetchBlocked() {
+			p.speculation.fetchWrongPath(p)
+		} else if p.fetchMiss(nextInstruction) {
+			p.caches.fetchStalls++
+			p.explainCache(nextInstruction, categoryICache)
+		} else if why, h := p.hazardCause(nextInstruction); why != causeNone {
+			p.stall(nextInstruction, why, h)
 		} else {
-			p.insertInstruction(nextInstruction)
+			p.issue(nextInstruction)
 		}
 	}
+	if p.issueWidth > 1 {
+		p.fillBundle()
+	}
 
-	// for _, instruction := range p.executingInstructions {
-	// 	fmt.Print(" - PC: ", instruction.PC, " | ")
-	// 	isa.ExecuteStage(isa.Stage(instruction.CurrentStage), instruction.Instruction)
-	// }
-	// fmt.Print("\n")
+	p.recordDiagram()
 
 	active := make([]*isa.PipelineInstruction, 0)
 	for _, instruction := range p.executingInstructions {
 		if !instruction.HasCompleted {
 			active = append(active, instruction)
+		} else if p.caches != nil {
+			p.caches.retire(instruction)
 		}
 	}
 	p.executingInstructions = active
 }
 
-func Run(instructions []isa.Instruction, forwarding bool, data_hazard bool, control_hazard bool, file_path string) {
-	p := NewPipeline(instructions, forwarding, data_hazard, control_hazard, file_path)
+// Run simulates the pipeline to completion, reports the result and returns
+// the finished pipeline.
+func Run(instructions []isa.Instruction, cfg Config) *Pipeline {
+	p := NewPipeline(instructions, cfg)
 
 	for !p.hasCompleted() {
 		p.CurrentCycle++
 		p.Step()
 	}
 	p.printResult()
+	if err := p.relocate(); err != nil {
+		fmt.Printf("Programa não relocado: %v\n", err)
+	}
 	p.writeFile()
+	p.writeDiagram()
+	p.writeExplanations()
+	p.writeProgram()
+	return p
 }
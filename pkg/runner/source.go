@@ -0,0 +1,105 @@
+package runner
+
+import (
+	"fmt"
+	"riscv-instruction-encoder/pkg/decoder"
+	"riscv-instruction-encoder/pkg/isa"
+	"riscv-instruction-encoder/pkg/sim"
+	"riscv-instruction-encoder/pkg/trace"
+)
+
+// source feeds the pipeline the instructions to issue, in fetch order. next
+// returns nil once the stream is exhausted.
+type source interface {
+	next() *isa.PipelineInstruction
+}
+
+// staticSource walks the program in address order, so every branch falls
+// through and every loop body runs once.
+type staticSource struct {
+	program []*isa.PipelineInstruction
+	index   int
+}
+
+func (s *staticSource) next() *isa.PipelineInstruction {
+	if s.index >= len(s.program) {
+		return nil
+	}
+	instr := s.program[s.index]
+	s.index++
+	return instr
+}
+
+// dynamicSource executes each instruction on the functional simulator as it
+// is fetched, so the pipeline sees the stream the program really runs,
+// including taken branches, jumps and loop iterations.
+type dynamicSource struct {
+	machine  *sim.Machine
+	limit    int
+	count    int
+	err      error
+	hitLimit bool
+}
+
+func (s *dynamicSource) next() *isa.PipelineInstruction {
+	if s.err != nil || s.machine.State.Halted {
+		return nil
+	}
+	if s.limit > 0 && s.count >= s.limit {
+		s.hitLimit = true
+		return nil
+	}
+
+	pc := s.machine.State.PC
+	inst, err := s.machine.Step()
+	if err != nil {
+		s.err = fmt.Errorf("execução em 0x%08X: %w", pc, err)
+		return nil
+	}
+	s.count++
+
+	meta := inst.GetMeta()
+	return &isa.PipelineInstruction{
+		Instruction: inst,
+		Id:          s.count,
+		OriginalPC:  int(pc),
+		NextPC:      int(s.machine.State.PC),
+		Address:     s.machine.State.Latch.Address,
+		HasAddress:  meta.IsLoad || meta.IsStore,
+	}
+}
+
+// traceSource replays a recorded dynamic trace, so hazards are analysed on
+// the retired stream without executing anything.
+type traceSource struct {
+	records []trace.Record
+	index   int
+	err     error
+}
+
+func (s *traceSource) next() *isa.PipelineInstruction {
+	if s.err != nil || s.index >= len(s.records) {
+		return nil
+	}
+	record := s.records[s.index]
+	inst := decoder.DecodeInstruction(record.Insn)
+	if inst == nil {
+		s.err = fmt.Errorf("opcode %02X não reconhecido em 0x%08X", record.Insn&0x7F, record.PC)
+		return nil
+	}
+	s.index++
+
+	nextPC := record.PC + 4
+	if s.index < len(s.records) {
+		nextPC = s.records[s.index].PC
+	} else if target, ok := inst.(isa.BranchTarget); ok && record.Taken {
+		nextPC = target.Target(record.PC)
+	}
+
+	return &isa.PipelineInstruction{
+		Instruction: inst,
+		Id:          s.index,
+		OriginalPC:  int(record.PC),
+		NextPC:      int(nextPC),
+	}
+}
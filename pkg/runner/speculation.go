@@ -0,0 +1,127 @@
+package runner
+
+import (
+	"riscv-instruction-encoder/pkg/hazard"
+	"riscv-instruction-encoder/pkg/isa"
+	"riscv-instruction-encoder/pkg/predictor"
+)
+
+// PredictionStats summarises how a predictor did over one run.
+type PredictionStats struct {
+	Predictions    int
+	Correct        int
+	PenaltyCycles  int
+	FlushedInstrs  int
+	Mispredictions int
+	// Redirects counts the correctly predicted taken branches whose target
+	// was only known in decode.
+	Redirects int
+}
+
+func (s PredictionStats) Accuracy() float64 {
+	if s.Predictions == 0 {
+		return 0
+	}
+	return float64(s.Correct) / float64(s.Predictions) * 100
+}
+
+// speculation tracks the control instructions in flight when the runner
+// fetches past them with a predictor instead of stalling.
+type speculation struct {
+	predictor predictor.Predictor
+	inflight  []*isa.PipelineInstruction
+	// mispredicted is the oldest branch whose predicted path is wrong; while
+	// it is unresolved every fetch slot goes down the wrong path.
+	mispredicted *isa.PipelineInstruction
+	wrongPath    []*isa.PipelineInstruction
+	stats        PredictionStats
+	// redirect is a correctly predicted taken branch whose target the
+	// predictor did not know at fetch; until it leaves decode the fetch slot
+	// goes down the fall-through path.
+	redirect *isa.PipelineInstruction
+}
+
+func isControl(instr *isa.PipelineInstruction) bool {
+	return instr.Instruction.GetMeta().IsControl()
+}
+
+// predict looks up the predictor for an issued control instruction and
+// remembers whether the fetch that follows is on the wrong path.
+func (s *speculation) predict(instr *isa.PipelineInstruction) {
+	predicted := s.predictor.Predict(uint32(instr.OriginalPC), instr.Instruction)
+	s.stats.Predictions++
+	s.inflight = append(s.inflight, instr)
+	if int(predicted) == instr.NextPC {
+		s.stats.Correct++
+		if predicted != uint32(instr.OriginalPC)+4 && !predictor.KnowsTargets(s.predictor) {
+			s.stats.Redirects++
+			s.redirect = instr
+		}
+		return
+	}
+	s.stats.Mispredictions++
+	if s.mispredicted == nil {
+		s.mispredicted = instr
+	}
+}
+
+// fetchBlocked reports whether the fetch slot is spent on the wrong path.
+func (s *speculation) fetchBlocked() bool {
+	return s.mispredicted != nil || s.redirect != nil
+}
+
+// resolve trains the predictor with every branch that reached its
+// resolution stage and flushes the wrong path of a mispredicted one, or of
+// a taken branch that has just computed its target in decode.
+func (s *speculation) resolve(p *Pipeline) {
+	if s.redirect != nil && isa.Stage(s.redirect.CurrentStage) > p.decodeStage() {
+		s.flush(p)
+	}
+	remaining := s.inflight[:0]
+	for _, instr := range s.inflight {
+		if !hazard.IsResolved(*instr, p.hazardConfig()) {
+			remaining = append(remaining, instr)
+			continue
+		}
+		s.predictor.Update(uint32(instr.OriginalPC), instr.Instruction, uint32(instr.NextPC))
+		if instr == s.mispredicted {
+			s.flush(p)
+		}
+	}
+	s.inflight = remaining
+}
+
+func (s *speculation) flush(p *Pipeline) {
+	flushed := make(map[*isa.PipelineInstruction]bool)
+	for _, wrong := range s.wrongPath {
+		flushed[wrong] = true
+		if p.diagram != nil {
+			p.diagram.mark(p.CurrentCycle, wrong, cellFlush)
+		}
+	}
+	active := p.executingInstructions[:0]
+	for _, instr := range p.executingInstructions {
+		if !flushed[instr] {
+			active = append(active, instr)
+		}
+	}
+	p.executingInstructions = active
+	s.stats.FlushedInstrs += len(s.wrongPath)
+	s.wrongPath = nil
+	s.mispredicted = nil
+	s.redirect = nil
+}
+
+// fetchWrongPath spends the fetch slot on an instruction that will be
+// flushed when the mispredicted branch resolves.
+func (s *speculation) fetchWrongPath(p *Pipeline) {
+	wrong := &isa.PipelineInstruction{
+		Instruction:  isa.NewNOP(),
+		CurrentStage: int(isa.IF),
+		HasStarted:   true,
+		Id:           wrongPathId,
+	}
+	s.wrongPath = append(s.wrongPath, wrong)
+	s.stats.PenaltyCycles++
+	p.executingInstructions = append(p.executingInstructions, wrong)
+}
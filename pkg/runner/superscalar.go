@@ -0,0 +1,142 @@
+package runner
+
+import (
+	"fmt"
+	"riscv-instruction-encoder/pkg/isa"
+)
+
+// IssueConfig describes an in-order superscalar front end: up to Width
+// instructions issue together, in program order, as long as the bundle
+// keeps to the pairing rules and its instructions are independent.
+type IssueConfig struct {
+	Width int
+	// MemoryOps and Branches limit the loads/stores and the branches/jumps
+	// in one bundle; zero means no limit.
+	MemoryOps int
+	Branches  int
+}
+
+// Why a slot of the bundle stayed empty.
+const (
+	emptyHazard = iota
+	emptyDependence
+	emptyMemory
+	emptyBranch
+	emptyFetch
+	emptyReasons
+)
+
+var emptyNames = [emptyReasons]string{"hazard", "dependência no par", "limite de memória", "limite de desvios", "sem instrução"}
+
+// SlotStats counts, for one issue slot, the cycles it carried an
+// instruction and why it was empty otherwise.
+type SlotStats struct {
+	Issued int
+	Empty  [emptyReasons]int
+}
+
+// fillBundle issues into the slots after the first. Issue is in order, so
+// the first slot that cannot be filled leaves the rest of the bundle empty
+// for the same reason.
+func (p *Pipeline) fillBundle() {
+	blocked := -1
+	if len(p.bundle) == 0 {
+		blocked = p.emptyReason(p.pending)
+		p.slots[0].Empty[blocked]++
+	} else {
+		p.slots[0].Issued++
+	}
+	for slot := 1; slot < p.issueWidth; slot++ {
+		if blocked < 0 {
+			next := p.fetch()
+			if next != nil {
+				next.CurrentStage = int(isa.IF)
+			}
+			reason, ok := p.pair(next)
+			if ok {
+				p.issue(next)
+				p.slots[slot].Issued++
+				continue
+			}
+			blocked = reason
+		}
+		p.slots[slot].Empty[blocked]++
+	}
+}
+
+// emptyReason explains a slot left empty because next could not issue.
+func (p *Pipeline) emptyReason(next *isa.PipelineInstruction) int {
+	if next == nil || (p.speculation != nil && p.speculation.fetchBlocked()) || p.fetchMiss(next) {
+		return emptyFetch
+	}
+	return emptyHazard
+}
+
+// pair checks whether next may join the bundle issued this cycle.
+func (p *Pipeline) pair(next *isa.PipelineInstruction) (int, bool) {
+	if next == nil || p.fetchMiss(next) {
+		return emptyFetch, false
+	}
+	last := p.bundle[len(p.bundle)-1]
+	if p.speculation != nil && isControl(last) {
+		// the next fetch address is only known once the branch is predicted
+		return emptyFetch, false
+	}
+
+	meta := next.Instruction.GetMeta()
+	memoryOps, branches := 0, 0
+	for _, mate := range p.bundle {
+		mateMeta := mate.Instruction.GetMeta()
+		if mateMeta.IsLoad || mateMeta.IsStore {
+			memoryOps++
+		}
+		if mateMeta.IsControl() {
+			branches++
+		}
+	}
+	if (meta.IsLoad || meta.IsStore) && p.pairing.MemoryOps > 0 && memoryOps >= p.pairing.MemoryOps {
+		return emptyMemory, false
+	}
+	if meta.IsControl() && p.pairing.Branches > 0 && branches >= p.pairing.Branches {
+		return emptyBranch, false
+	}
+
+	// no result of the bundle can reach a mate in the same cycle, and two
+	// writes to one register would race
+	for _, mate := range p.bundle {
+		if rd, ok := mate.Instruction.GetMeta().Dest(); ok {
+			if meta.Reads(rd) {
+				return emptyDependence, false
+			}
+			if rd2, ok := meta.Dest(); ok && rd2 == rd {
+				return emptyDependence, false
+			}
+		}
+	}
+
+	if p.hasHazard(next) {
+		return emptyHazard, false
+	}
+	return 0, true
+}
+
+func (p *Pipeline) printIssueStats() {
+	fmt.Printf("Largura de emissão: %d (memória por ciclo: %s, desvios por ciclo: %s)\n",
+		p.issueWidth, limitText(p.pairing.MemoryOps), limitText(p.pairing.Branches))
+	for slot, stats := range p.slots {
+		fmt.Printf("Slot %d: %d emitidas", slot, stats.Issued)
+		for reason, count := range stats.Empty {
+			if count > 0 {
+				fmt.Printf(", %s %d", emptyNames[reason], count)
+			}
+		}
+		fmt.Println()
+	}
+}
+
+func limitText(limit int) string {
+	if limit == 0 {
+		return "sem limite"
+	}
+	return fmt.Sprint(limit)
+}
@@ -0,0 +1,167 @@
+// Package scheduler reorders the instructions of every basic block so that
+// independent work fills the cycles a dependent instruction would otherwise
+// wait, leaving fewer gaps for the runner to pad with NOPs.
+package scheduler
+
+import (
+	"riscv-instruction-encoder/pkg/hazard"
+	"riscv-instruction-encoder/pkg/isa"
+)
+
+type Stats struct {
+	Blocks int
+	// Moved counts the instructions that left their original position.
+	Moved int
+	// Gaps and GapsBefore are the idle cycles the scheduling model expects
+	// inside blocks after and before reordering.
+	Gaps       int
+	GapsBefore int
+}
+
+// Schedule list-schedules every basic block for the given pipeline. Blocks
+// keep their size and place, and a block ending in a branch or jump keeps it
+// last, so every branch target stays valid.
+func Schedule(program []isa.Instruction, cfg hazard.Config) ([]isa.Instruction, Stats) {
+	var stats Stats
+	leader := isa.Leaders(program)
+	s := &state{cfg: cfg}
+	original := &state{cfg: cfg}
+
+	start := 0
+	for end := 1; end <= len(program); end++ {
+		// the end of the program closes the last block
+		if end < len(program) && !leader[end] {
+			continue
+		}
+		stats.Blocks++
+		for i := start; i < end; i++ {
+			original.place(program[i], i)
+		}
+		s.block(program, start, end)
+		start = end
+	}
+
+	for pos, index := range s.origin {
+		if pos != index {
+			stats.Moved++
+		}
+	}
+	stats.Gaps = s.gaps
+	stats.GapsBefore = original.gaps
+	return s.program, stats
+}
+
+// state is the schedule built so far, with the cycle each instruction
+// issues in, so that blocks see the latency of what precedes them.
+type state struct {
+	cfg     hazard.Config
+	program []isa.Instruction
+	origin  []int
+	cycles  []int
+	gaps    int
+}
+
+func (s *state) lastCycle() int {
+	if len(s.cycles) == 0 {
+		return 0
+	}
+	return s.cycles[len(s.cycles)-1]
+}
+
+// earliest is the first cycle inst may issue in after what is scheduled.
+// Only the instructions still in flight can delay it.
+func (s *state) earliest(inst isa.Instruction) int {
+	cycle := s.lastCycle() + 1
+	for i := len(s.program) - 1; i >= 0 && s.lastCycle()-s.cycles[i] < s.cfg.Depth(); i-- {
+		if at := s.cycles[i] + hazard.MinDistance(s.program[i], inst, s.cfg); at > cycle {
+			cycle = at
+		}
+	}
+	return cycle
+}
+
+// place appends inst as soon as the pipeline accepts it.
+func (s *state) place(inst isa.Instruction, index int) {
+	cycle := s.earliest(inst)
+	s.gaps += cycle - s.lastCycle() - 1
+	s.program = append(s.program, inst)
+	s.origin = append(s.origin, index)
+	s.cycles = append(s.cycles, cycle)
+}
+
+// ordered reports whether second must stay after first. Keeping a pinned
+// instruction ordered with every other one keeps it at its address.
+func ordered(first, second isa.InstructionMeta) bool {
+	return isPinned(first) || isPinned(second) || !isa.Independent(first, second)
+}
+
+// isPinned matches the system instructions, whose effects go beyond their
+// registers, and the instructions whose result depends on their own
+// address: AUIPC and the link of JAL and JALR.
+func isPinned(meta isa.InstructionMeta) bool {
+	if meta.IsSystem {
+		return true
+	}
+	switch meta.Name {
+	case "AUIPC", "JAL", "JALR":
+		return true
+	}
+	return false
+}
+
+// block schedules program[start:end]. At every cycle the ready instruction
+// with the longest latency-weighted path to the end of the block issues;
+// ties keep the program order.
+func (s *state) block(program []isa.Instruction, start, end int) {
+	n := end - start
+	preds := make([][]int, n)
+	succs := make([][]int, n)
+	for j := 0; j < n; j++ {
+		for i := 0; i < j; i++ {
+			if ordered(program[start+i].GetMeta(), program[start+j].GetMeta()) {
+				preds[j] = append(preds[j], i)
+				succs[i] = append(succs[i], j)
+			}
+		}
+	}
+
+	height := make([]int, n)
+	for i := n - 1; i >= 0; i-- {
+		for _, j := range succs[i] {
+			if h := hazard.MinDistance(program[start+i], program[start+j], s.cfg) + height[j]; h > height[i] {
+				height[i] = h
+			}
+		}
+	}
+
+	// a branch or jump closing the block issues last
+	last := -1
+	if program[end-1].GetMeta().IsControl() {
+		last = n - 1
+	}
+
+	done := make([]bool, n)
+	for left := n; left > 0; left-- {
+		best, bestCycle := -1, 0
+		for i := 0; i < n; i++ {
+			if done[i] || (i == last && left > 1) || !allDone(preds[i], done) {
+				continue
+			}
+			cycle := s.earliest(program[start+i])
+			if best < 0 || cycle < bestCycle || (cycle == bestCycle && height[i] > height[best]) {
+				best, bestCycle = i, cycle
+			}
+		}
+		done[best] = true
+		s.place(program[start+best], start+best)
+	}
+}
+
+func allDone(indices []int, done []bool) bool {
+	for _, i := range indices {
+		if !done[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,79 @@
+package scoreboard
+
+import (
+	"fmt"
+	"os"
+	"riscv-instruction-encoder/pkg/isa"
+	"strings"
+)
+
+// WriteReport writes the instruction status table, followed by the
+// functional unit status and the register result status at the end of
+// every cycle, laid out like the classic scoreboard tables.
+func (r *Result) WriteReport(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var b strings.Builder
+	for unit, u := range r.Config.Units {
+		fmt.Fprintf(&b, "%s: %d unidades, latência %d\n", isa.Unit(unit), u.Count, u.Latency)
+	}
+
+	fmt.Fprintf(&b, "\nStatus das instruções\n")
+	fmt.Fprintf(&b, "%-10s  %-6s %-5s %8s %8s %10s %8s\n", "PC", "Instr.", "Un.", "Emissão", "Leitura", "Execução", "Escrita")
+	for _, e := range r.Entries {
+		fmt.Fprintf(&b, "0x%08X  %-6s %-5s %8d %8d %10d %8d\n", e.Index*4, e.Instruction.GetMeta().Name, e.Unit,
+			e.Issue, e.Read, e.ExecComplete, e.Write)
+	}
+
+	for _, s := range r.Snapshots {
+		fmt.Fprintf(&b, "\n== Ciclo %d\n", s.Cycle)
+		fmt.Fprintf(&b, "%-5s %-5s %-6s %-4s %-4s %-4s %-5s %-5s %-4s %s\n",
+			"Un.", "Ocup.", "Op", "Fi", "Fj", "Fk", "Qj", "Qk", "Rj", "Rk")
+		for _, u := range s.Units {
+			if !u.Busy {
+				fmt.Fprintf(&b, "%-5s %s\n", u.Name, "Não")
+				continue
+			}
+			line := fmt.Sprintf("%-5s %-5s %-6s %-4s %-4s %-4s %-5s %-5s %-4s %-4s", u.Name, "Sim", u.Op,
+				register(u.Fi), register(u.Fj), register(u.Fk), u.Qj, u.Qk, yesNo(u.Fj, u.Rj), yesNo(u.Fk, u.Rk))
+			b.WriteString(strings.TrimRight(line, " ") + "\n")
+		}
+
+		var pending []string
+		for reg, name := range s.Registers {
+			if name != "" {
+				pending = append(pending, fmt.Sprintf("x%d=%s", reg, name))
+			}
+		}
+		if len(pending) == 0 {
+			pending = append(pending, "-")
+		}
+		fmt.Fprintf(&b, "Registradores: %s\n", strings.Join(pending, " "))
+	}
+
+	_, err = file.WriteString(b.String())
+	return err
+}
+
+// register names a field of the status table; x0 stands for no register.
+func register(reg int) string {
+	if reg == 0 {
+		return ""
+	}
+	return fmt.Sprintf("x%d", reg)
+}
+
+// yesNo is the Rj/Rk column; it is empty for an unused source.
+func yesNo(reg int, ready bool) string {
+	switch {
+	case reg == 0:
+		return ""
+	case ready:
+		return "Sim"
+	}
+	return "Não"
+}
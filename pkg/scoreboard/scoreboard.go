@@ -0,0 +1,306 @@
+// Package scoreboard models CDC 6600 style dynamic scheduling. Each
+// instruction goes through four phases: issue, when its functional unit is
+// free and no active instruction writes the same register (WAW); read
+// operands, once no active instruction is still to produce them (RAW);
+// execute; and write result, once no earlier instruction still has to read
+// the register being overwritten (WAR).
+//
+// Every phase decision of a cycle is taken on the state at its start, so a
+// result written in one cycle is read in the next. Branches are not
+// predicted: issue stops at a branch or jump until it has written.
+package scoreboard
+
+import (
+	"fmt"
+	"riscv-instruction-encoder/pkg/isa"
+)
+
+// UnitConfig is the number of functional units of a kind and how many
+// cycles they take to execute. Units are not pipelined.
+type UnitConfig struct {
+	Count   int
+	Latency int
+}
+
+type Config struct {
+	Units [isa.NumUnits]UnitConfig
+}
+
+func DefaultConfig() Config {
+	return Config{Units: [isa.NumUnits]UnitConfig{
+		isa.UnitALU: {Count: 2, Latency: 1},
+		isa.UnitMUL: {Count: 1, Latency: 3},
+		isa.UnitDIV: {Count: 1, Latency: 10},
+		isa.UnitLSU: {Count: 1, Latency: 2},
+		isa.UnitBRU: {Count: 1, Latency: 1},
+	}}
+}
+
+// Entry is the instruction status: the unit it ran on and the cycle of
+// every phase.
+type Entry struct {
+	Index        int
+	Instruction  isa.Instruction
+	Unit         string
+	Issue        int
+	Read         int
+	ExecComplete int
+	Write        int
+}
+
+// UnitStatus is a row of the functional unit status table.
+type UnitStatus struct {
+	Name   string
+	Busy   bool
+	Op     string
+	Fi     int
+	Fj, Fk int
+	Qj, Qk string
+	Rj, Rk bool
+}
+
+// Snapshot is the scoreboard at the end of a cycle. Registers holds the
+// unit that will write each register, or "".
+type Snapshot struct {
+	Cycle     int
+	Units     []UnitStatus
+	Registers [32]string
+}
+
+// Stalls counts the cycles issue stopped and the cycles finished results
+// waited to be written, by cause.
+type Stalls struct {
+	Structural int
+	WAW        int
+	Branch     int
+	WAR        int
+}
+
+type Result struct {
+	Config    Config
+	Entries   []*Entry
+	Snapshots []Snapshot
+	Stalls    Stalls
+	Cycles    int
+}
+
+func (r *Result) IPC() float64 {
+	if r.Cycles == 0 {
+		return 0
+	}
+	return float64(len(r.Entries)) / float64(r.Cycles)
+}
+
+// unit is a functional unit and the status fields of the classic table:
+// Fi the destination, Fj/Fk the sources, Qj/Qk the units producing them
+// and Rj/Rk whether they are ready and not yet read.
+type unit struct {
+	name    string
+	kind    isa.Unit
+	latency int
+	entry   *Entry
+	fi      int
+	fj, fk  int
+	qj, qk  *unit
+	rj, rk  bool
+}
+
+func (u *unit) busy() bool {
+	return u.entry != nil
+}
+
+type board struct {
+	units   []*unit
+	result  [32]*unit
+	program []isa.Instruction
+	next    int
+	done    int
+	outcome *Result
+}
+
+// Run schedules program in its static order until every instruction has
+// written its result.
+func Run(program []isa.Instruction, cfg Config) (*Result, error) {
+	b := &board{program: program, outcome: &Result{Config: cfg}}
+	for kind, u := range cfg.Units {
+		if u.Count < 1 || u.Latency < 1 {
+			return nil, fmt.Errorf("configuração inválida para %s", isa.Unit(kind))
+		}
+		for i := 1; i <= u.Count; i++ {
+			name := isa.Unit(kind).String()
+			if u.Count > 1 {
+				name += fmt.Sprint(i)
+			}
+			b.units = append(b.units, &unit{name: name, kind: isa.Unit(kind), latency: u.Latency})
+		}
+	}
+
+	limit := (len(program) + 1) * 100
+	for cycle := 1; b.done < len(program); cycle++ {
+		if cycle > limit {
+			return nil, fmt.Errorf("sem progresso após %d ciclos", limit)
+		}
+		b.step(cycle)
+		b.outcome.Snapshots = append(b.outcome.Snapshots, b.snapshot(cycle))
+		b.outcome.Cycles = cycle
+	}
+	return b.outcome, nil
+}
+
+// step decides every phase on the state at the start of the cycle, then
+// applies the decisions: reads, writes and finally the issue. Issue is
+// decided before the writes too, so a unit or register released by a write
+// can only be reused from the next cycle on.
+func (b *board) step(cycle int) {
+	var reads, writes []*unit
+	for _, u := range b.units {
+		if !u.busy() {
+			continue
+		}
+		e := u.entry
+		switch {
+		case e.Read == 0 && e.Issue < cycle && u.rj && u.rk:
+			reads = append(reads, u)
+		case e.Read != 0 && e.ExecComplete == 0 && e.Read+u.latency == cycle:
+			e.ExecComplete = cycle
+		case e.ExecComplete != 0 && e.ExecComplete < cycle:
+			if b.hasWAR(u) {
+				b.outcome.Stalls.WAR++
+			} else {
+				writes = append(writes, u)
+			}
+		}
+	}
+	issue := b.canIssue()
+
+	for _, u := range reads {
+		u.entry.Read = cycle
+		u.rj, u.rk = false, false
+	}
+	for _, u := range writes {
+		b.write(u, cycle)
+	}
+	if issue != nil {
+		b.issue(issue, cycle)
+	}
+}
+
+// hasWAR reports whether an instruction that has not read its operands
+// yet still needs the old value of the register u is about to write.
+func (b *board) hasWAR(u *unit) bool {
+	if u.fi == 0 {
+		return false
+	}
+	for _, f := range b.units {
+		if f == u || !f.busy() {
+			continue
+		}
+		if (f.fj == u.fi && f.rj) || (f.fk == u.fi && f.rk) {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *board) write(u *unit, cycle int) {
+	u.entry.Write = cycle
+	for _, f := range b.units {
+		if f.qj == u {
+			f.qj, f.rj = nil, true
+		}
+		if f.qk == u {
+			f.qk, f.rk = nil, true
+		}
+	}
+	if u.fi != 0 && b.result[u.fi] == u {
+		b.result[u.fi] = nil
+	}
+	u.entry = nil
+	b.done++
+}
+
+// canIssue returns the free unit the next instruction issues to, or nil
+// when it has to wait.
+func (b *board) canIssue() *unit {
+	if b.next >= len(b.program) {
+		return nil
+	}
+	meta := b.program[b.next].GetMeta()
+	for _, u := range b.units {
+		if u.busy() && u.entry.Instruction.GetMeta().IsControl() {
+			b.outcome.Stalls.Branch++
+			return nil
+		}
+	}
+	if rd, ok := meta.Dest(); ok && b.result[rd] != nil {
+		b.outcome.Stalls.WAW++
+		return nil
+	}
+	kind := isa.UnitOf(meta)
+	for _, u := range b.units {
+		if u.kind == kind && !u.busy() {
+			return u
+		}
+	}
+	b.outcome.Stalls.Structural++
+	return nil
+}
+
+func (b *board) issue(u *unit, cycle int) {
+	inst := b.program[b.next]
+	meta := inst.GetMeta()
+	e := &Entry{Index: b.next, Instruction: inst, Unit: u.name, Issue: cycle}
+	b.outcome.Entries = append(b.outcome.Entries, e)
+	b.next++
+
+	u.entry = e
+	u.fi, _ = meta.Dest()
+	u.fj, u.fk = 0, 0
+	if len(meta.Rs) > 0 {
+		u.fj = meta.Rs[0]
+	}
+	if len(meta.Rs) > 1 {
+		u.fk = meta.Rs[1]
+	}
+	u.qj = b.producer(u.fj)
+	u.qk = b.producer(u.fk)
+	u.rj = u.qj == nil
+	u.rk = u.qk == nil
+	if u.fi != 0 {
+		b.result[u.fi] = u
+	}
+}
+
+// producer is the unit that will write reg, nil when the register file
+// already holds its value.
+func (b *board) producer(reg int) *unit {
+	if reg == 0 {
+		return nil
+	}
+	return b.result[reg]
+}
+
+func (b *board) snapshot(cycle int) Snapshot {
+	s := Snapshot{Cycle: cycle}
+	for _, u := range b.units {
+		status := UnitStatus{Name: u.name, Busy: u.busy()}
+		if u.busy() {
+			status.Op = u.entry.Instruction.GetMeta().Name
+			status.Fi, status.Fj, status.Fk = u.fi, u.fj, u.fk
+			status.Rj, status.Rk = u.rj, u.rk
+			if u.qj != nil {
+				status.Qj = u.qj.name
+			}
+			if u.qk != nil {
+				status.Qk = u.qk.name
+			}
+		}
+		s.Units = append(s.Units, status)
+	}
+	for reg, u := range b.result {
+		if u != nil {
+			s.Registers[reg] = u.name
+		}
+	}
+	return s
+}
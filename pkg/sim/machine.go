@@ -0,0 +1,136 @@
+package sim
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"riscv-instruction-encoder/pkg/decoder"
+	"riscv-instruction-encoder/pkg/isa"
+	"riscv-instruction-encoder/pkg/memory"
+)
+
+var ErrStepLimit = errors.New("limite de instruções atingido")
+
+// StackTop is the initial stack pointer of programs loaded from an ELF,
+// below the 0x80000000 region where bare-metal test programs are linked.
+const StackTop = 0x7FFFFFF0
+
+// Machine is a functional, one-instruction-at-a-time RV32I simulator, with
+// the Zicsr instructions over the CSRs isa.State implements, FENCE and MRET.
+// Each instruction runs its five Execute* stages back to back on the shared
+// architectural state.
+type Machine struct {
+	State   *isa.State
+	Memory  *memory.Memory
+	Retired int
+	// End is the first address past the loaded program; reaching it halts.
+	End uint32
+	// HeapStart is the first free address past the loaded image.
+	HeapStart uint32
+}
+
+func NewMachine() *Machine {
+	mem := memory.New()
+	return &Machine{State: isa.NewState(mem), Memory: mem}
+}
+
+// LoadProgram writes the instruction words into memory starting at base and
+// points the PC at the first one.
+func (m *Machine) LoadProgram(instructions []isa.RawInstruction, base uint32) error {
+	if base%4 != 0 {
+		return fmt.Errorf("endereço base desalinhado: 0x%08X", base)
+	}
+	for i, inst := range instructions {
+		if err := m.Memory.StoreInstruction(base+uint32(i*4), inst.Value); err != nil {
+			return err
+		}
+	}
+	m.State.PC = base
+	m.End = base + uint32(len(instructions)*4)
+	m.HeapStart = m.End
+	return nil
+}
+
+// LoadELF loads the segments of an ELF executable and starts at its entry.
+// Without a program end, the run stops only on a halt, trap or step limit.
+func (m *Machine) LoadELF(filePath string) error {
+	entry, end, err := m.Memory.LoadELF(filePath)
+	if err != nil {
+		return err
+	}
+	m.Memory.Stats = memory.Stats{}
+	m.State.PC = entry
+	m.State.WriteReg(2, StackTop)
+	m.End = 0
+	m.HeapStart = end
+	return nil
+}
+
+// Fetch decodes the instruction at the current PC without executing it.
+func (m *Machine) Fetch() (isa.Instruction, error) {
+	s := m.State
+	word, err := m.Memory.Fetch(s.PC)
+	if err != nil {
+		return nil, fmt.Errorf("fetch em 0x%08X: %w", s.PC, err)
+	}
+	inst := decoder.DecodeInstruction(word)
+	if inst == nil {
+		return nil, fmt.Errorf("opcode %02X não reconhecido em 0x%08X", word&0x7F, s.PC)
+	}
+	return inst, nil
+}
+
+// Step executes one instruction and returns it.
+func (m *Machine) Step() (isa.Instruction, error) {
+	s := m.State
+	inst, err := m.Fetch()
+	if err != nil {
+		return nil, err
+	}
+
+	s.Latch = isa.Latch{}
+	for _, stage := range isa.Stages {
+		isa.ExecuteStage(stage, inst, s)
+		if s.Trap != nil {
+			return inst, s.Trap
+		}
+	}
+
+	s.PC = s.Latch.NextPC
+	m.Retired++
+	s.Instret++
+	if s.Env != nil {
+		s.Env.Poll(s)
+	}
+	if m.End != 0 && s.PC == m.End {
+		s.Halted = true
+	}
+	return inst, nil
+}
+
+// Run steps until the program halts, traps or maxSteps instructions retire.
+func (m *Machine) Run(maxSteps int) error {
+	for !m.State.Halted {
+		if maxSteps > 0 && m.Retired >= maxSteps {
+			return ErrStepLimit
+		}
+		if _, err := m.Step(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *Machine) DumpRegisters(w io.Writer) {
+	s := m.State
+	fmt.Fprintf(w, "PC: 0x%08X  instruções executadas: %d\n", s.PC, m.Retired)
+	for i := 0; i < len(s.Regs); i += 4 {
+		for j := i; j < i+4; j++ {
+			sep := "  "
+			if j == i+3 {
+				sep = "\n"
+			}
+			fmt.Fprintf(w, "x%-2d %-4s 0x%08X%s", j, isa.RegisterNames[j], s.Regs[j], sep)
+		}
+	}
+}
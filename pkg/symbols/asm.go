@@ -0,0 +1,71 @@
+package symbols
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// LoadAsm collects the labels of an assembly source, assigning addresses by
+// counting the instructions that precede them from address 0.
+func LoadAsm(filePath string) (*Table, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao abrir arquivo: %w", err)
+	}
+	defer file.Close()
+
+	table := New()
+	var addr uint32
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexAny(line, "#;"); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+
+		for {
+			i := strings.Index(line, ":")
+			if i < 0 || strings.ContainsAny(line[:i], " \t,") {
+				break
+			}
+			table.Add(line[:i], addr)
+			line = strings.TrimSpace(line[i+1:])
+		}
+
+		if line == "" || strings.HasPrefix(line, ".") {
+			continue
+		}
+		addr += instructionSize(line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return table, nil
+}
+
+// instructionSize accounts for the pseudo-instructions that the assembler
+// expands into two instructions.
+func instructionSize(line string) uint32 {
+	fields := strings.FieldsFunc(line, func(r rune) bool {
+		return r == ' ' || r == '\t' || r == ','
+	})
+	switch strings.ToLower(fields[0]) {
+	case "la", "call", "tail":
+		return 8
+	case "li":
+		if len(fields) < 3 {
+			return 4
+		}
+		v, err := strconv.ParseInt(fields[2], 0, 64)
+		if err != nil || v < -2048 || v > 2047 {
+			return 8
+		}
+	}
+	return 4
+}
@@ -0,0 +1,33 @@
+package symbols
+
+import (
+	"debug/elf"
+	"fmt"
+)
+
+// LoadELF reads the function and label symbols of an ELF symbol table.
+func LoadELF(filePath string) (*Table, error) {
+	file, err := elf.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao abrir ELF: %w", err)
+	}
+	defer file.Close()
+
+	syms, err := file.Symbols()
+	if err != nil {
+		return nil, err
+	}
+
+	table := New()
+	for _, sym := range syms {
+		if sym.Name == "" || sym.Section == elf.SHN_UNDEF {
+			continue
+		}
+		switch elf.ST_TYPE(sym.Info) {
+		case elf.STT_FUNC, elf.STT_NOTYPE, elf.STT_OBJECT:
+			table.Add(sym.Name, uint32(sym.Value))
+		}
+	}
+
+	return table, nil
+}
@@ -0,0 +1,131 @@
+package symbols
+
+import (
+	"fmt"
+	"riscv-instruction-encoder/pkg/isa"
+	"sort"
+)
+
+type Symbol struct {
+	Name string
+	Addr uint32
+}
+
+// Table keeps the symbols sorted by address so the nearest symbol below a
+// PC can be found with a binary search.
+type Table struct {
+	symbols []Symbol
+}
+
+func New() *Table {
+	return &Table{}
+}
+
+func (t *Table) Add(name string, addr uint32) {
+	i := sort.Search(len(t.symbols), func(i int) bool {
+		return t.symbols[i].Addr > addr
+	})
+	t.symbols = append(t.symbols, Symbol{})
+	copy(t.symbols[i+1:], t.symbols[i:])
+	t.symbols[i] = Symbol{Name: name, Addr: addr}
+}
+
+func (t *Table) Len() int {
+	if t == nil {
+		return 0
+	}
+	return len(t.symbols)
+}
+
+// Relocate returns a copy of the table with every address passed through
+// move, e.g. after code was inserted into the program.
+func (t *Table) Relocate(move func(addr uint32) uint32) *Table {
+	if t == nil {
+		return nil
+	}
+	moved := New()
+	for _, sym := range t.symbols {
+		moved.Add(sym.Name, move(sym.Addr))
+	}
+	return moved
+}
+
+// Find returns the address of the first symbol called name.
+func (t *Table) Find(name string) (uint32, bool) {
+	if t == nil {
+		return 0, false
+	}
+	for _, sym := range t.symbols {
+		if sym.Name == name {
+			return sym.Addr, true
+		}
+	}
+	return 0, false
+}
+
+// Labels returns every symbol defined exactly at addr, in insertion order.
+func (t *Table) Labels(addr uint32) []string {
+	if t == nil {
+		return nil
+	}
+	var names []string
+	i := sort.Search(len(t.symbols), func(i int) bool {
+		return t.symbols[i].Addr >= addr
+	})
+	for ; i < len(t.symbols) && t.symbols[i].Addr == addr; i++ {
+		names = append(names, t.symbols[i].Name)
+	}
+	return names
+}
+
+// Lookup returns the closest symbol at or below addr.
+func (t *Table) Lookup(addr uint32) (Symbol, bool) {
+	if t == nil {
+		return Symbol{}, false
+	}
+	i := sort.Search(len(t.symbols), func(i int) bool {
+		return t.symbols[i].Addr > addr
+	})
+	if i == 0 {
+		return Symbol{}, false
+	}
+	// the first symbol of a run sharing the same address wins
+	sym := t.symbols[i-1]
+	for i-2 >= 0 && t.symbols[i-2].Addr == sym.Addr {
+		i--
+		sym = t.symbols[i-1]
+	}
+	return sym, true
+}
+
+// Format renders addr as symbol+offset (e.g. loop+0x8), or an empty string
+// when no symbol precedes it.
+func (t *Table) Format(addr uint32) string {
+	sym, ok := t.Lookup(addr)
+	if !ok {
+		return ""
+	}
+	if sym.Addr == addr {
+		return sym.Name
+	}
+	return fmt.Sprintf("%s+0x%X", sym.Name, addr-sym.Addr)
+}
+
+// Annotate describes where a branch or jump at pc transfers control, as a
+// suffix for listings. Instructions without a static target return "".
+func (t *Table) Annotate(pc uint32, inst isa.Instruction) string {
+	target, ok := inst.(isa.BranchTarget)
+	if !ok {
+		return ""
+	}
+	meta := inst.GetMeta()
+	if !meta.IsBranch && !meta.IsJump {
+		return ""
+	}
+
+	addr := target.Target(pc)
+	if name := t.Format(addr); name != "" {
+		return fmt.Sprintf("  # 0x%08X <%s>", addr, name)
+	}
+	return fmt.Sprintf("  # 0x%08X", addr)
+}
@@ -0,0 +1,52 @@
+package tomasulo
+
+import (
+	"fmt"
+	"os"
+	"riscv-instruction-encoder/pkg/isa"
+	"strings"
+)
+
+// WriteReport writes the cycle each instruction issued, executed, wrote its
+// result and committed, followed by the occupancy of the reorder buffer and
+// the reservation stations in every cycle.
+func (r *Result) WriteReport(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "ROB: %d entradas, emissão: %d, commit: %d, CDB: %d\n",
+		r.Config.ROBSize, r.Config.IssueWidth, r.Config.CommitWidth, r.Config.CDBWidth)
+	for unit, u := range r.Config.Units {
+		fmt.Fprintf(&b, "%s: %d estações, %d unidades, latência %d\n", isa.Unit(unit), u.Stations, u.Units, u.Latency)
+	}
+
+	fmt.Fprintf(&b, "\n%-10s  %-6s %-4s %8s %10s %7s %7s\n", "PC", "Instr.", "Un.", "Emissão", "Execução", "Escrita", "Commit")
+	for _, e := range r.Entries {
+		exec := fmt.Sprintf("%d-%d", e.ExecStart, e.ExecEnd)
+		if e.ExecStart == e.ExecEnd {
+			exec = fmt.Sprint(e.ExecStart)
+		}
+		fmt.Fprintf(&b, "0x%08X  %-6s %-4s %8d %10s %7d %7d\n", e.Index*4, e.Instruction.GetMeta().Name, e.Unit,
+			e.Issue, exec, e.Write, e.Commit)
+	}
+
+	fmt.Fprintf(&b, "\n%-6s %5s", "Ciclo", "ROB")
+	for unit := range r.Config.Units {
+		fmt.Fprintf(&b, " %6s", "RS "+isa.Unit(unit).String())
+	}
+	fmt.Fprintf(&b, " %8s %7s\n", "Emitidas", "Commits")
+	for _, s := range r.Samples {
+		fmt.Fprintf(&b, "%-6d %2d/%-2d", s.Cycle, s.ROB, r.Config.ROBSize)
+		for unit, n := range s.Stations {
+			fmt.Fprintf(&b, " %3d/%-2d", n, r.Config.Units[unit].Stations)
+		}
+		fmt.Fprintf(&b, " %8d %7d\n", s.Issued, s.Committed)
+	}
+
+	_, err = file.WriteString(b.String())
+	return err
+}
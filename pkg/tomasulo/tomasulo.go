@@ -0,0 +1,309 @@
+// Package tomasulo models an out-of-order core: instructions issue in order
+// into reservation stations with their registers renamed to reorder-buffer
+// entries, execute as soon as their operands are broadcast on the common
+// data bus, and commit in order from the reorder buffer.
+//
+// The model tracks timing only. Branches are not predicted, so issue stops
+// at a branch or jump until it has executed, like the in-order pipeline
+// without a predictor; loads wait until no earlier store is uncommitted,
+// and stores write memory when they commit.
+package tomasulo
+
+import (
+	"fmt"
+	"riscv-instruction-encoder/pkg/isa"
+)
+
+// UnitConfig sizes the reservation stations and the functional units of
+// one kind. Units are pipelined: each can start an instruction per cycle.
+type UnitConfig struct {
+	Stations int
+	Units    int
+	Latency  int
+}
+
+type Config struct {
+	ROBSize     int
+	IssueWidth  int
+	CommitWidth int
+	// CDBWidth is how many results can be broadcast per cycle.
+	CDBWidth int
+	Units    [isa.NumUnits]UnitConfig
+}
+
+func DefaultConfig() Config {
+	return Config{
+		ROBSize:     8,
+		IssueWidth:  1,
+		CommitWidth: 1,
+		CDBWidth:    1,
+		Units: [isa.NumUnits]UnitConfig{
+			isa.UnitALU: {Stations: 3, Units: 1, Latency: 1},
+			isa.UnitMUL: {Stations: 2, Units: 1, Latency: 3},
+			isa.UnitDIV: {Stations: 1, Units: 1, Latency: 10},
+			isa.UnitLSU: {Stations: 2, Units: 1, Latency: 2},
+			isa.UnitBRU: {Stations: 2, Units: 1, Latency: 1},
+		},
+	}
+}
+
+// Entry is a reorder-buffer entry and the cycle it went through every
+// phase in.
+type Entry struct {
+	Index       int
+	Instruction isa.Instruction
+	Unit        isa.Unit
+	Issue       int
+	ExecStart   int
+	ExecEnd     int
+	Write       int
+	Commit      int
+
+	dest     int
+	waiting  []*Entry
+	readyAt  int
+	hasValue bool
+}
+
+// Sample is the occupancy of the core at the end of a cycle.
+type Sample struct {
+	Cycle     int
+	ROB       int
+	Stations  [isa.NumUnits]int
+	Issued    int
+	Committed int
+}
+
+// Stalls counts the cycles issue stopped, by cause.
+type Stalls struct {
+	ROBFull      int
+	StationsFull int
+	Branch       int
+}
+
+type Result struct {
+	Config    Config
+	Entries   []*Entry
+	Samples   []Sample
+	Stalls    Stalls
+	Cycles    int
+	Committed int
+}
+
+func (r *Result) IPC() float64 {
+	if r.Cycles == 0 {
+		return 0
+	}
+	return float64(r.Committed) / float64(r.Cycles)
+}
+
+type core struct {
+	cfg      Config
+	program  []isa.Instruction
+	next     int
+	rob      []*Entry
+	rat      [32]*Entry
+	stations [isa.NumUnits][]*Entry
+	result   *Result
+}
+
+// Run executes program in its static order until every instruction has
+// committed.
+func Run(program []isa.Instruction, cfg Config) (*Result, error) {
+	if cfg.ROBSize < 1 || cfg.IssueWidth < 1 || cfg.CommitWidth < 1 || cfg.CDBWidth < 1 {
+		return nil, fmt.Errorf("configuração inválida: ROB, emissão, commit e CDB precisam de pelo menos 1")
+	}
+	for unit, u := range cfg.Units {
+		if u.Stations < 1 || u.Units < 1 || u.Latency < 1 {
+			return nil, fmt.Errorf("configuração inválida para %s", isa.Unit(unit))
+		}
+	}
+
+	c := &core{cfg: cfg, program: program, result: &Result{Config: cfg}}
+	// every instruction takes at most a few cycles per phase; the bound
+	// only guards against a configuration that can never make progress
+	limit := (len(program) + 1) * (cfg.ROBSize + 10) * 10
+	for cycle := 1; c.result.Committed < len(program); cycle++ {
+		if cycle > limit {
+			return nil, fmt.Errorf("sem progresso após %d ciclos", limit)
+		}
+		sample := Sample{Cycle: cycle}
+		sample.Committed = c.commit(cycle)
+		c.write(cycle)
+		c.execute(cycle)
+		sample.Issued = c.issue(cycle)
+
+		sample.ROB = len(c.rob)
+		for unit := range c.stations {
+			sample.Stations[unit] = len(c.stations[unit])
+		}
+		c.result.Samples = append(c.result.Samples, sample)
+		c.result.Cycles = cycle
+	}
+	return c.result, nil
+}
+
+// commit retires finished instructions from the head of the reorder buffer.
+func (c *core) commit(cycle int) int {
+	count := 0
+	for count < c.cfg.CommitWidth && len(c.rob) > 0 {
+		head := c.rob[0]
+		if head.Write == 0 || head.Write >= cycle {
+			break
+		}
+		head.Commit = cycle
+		if head.dest != 0 && c.rat[head.dest] == head {
+			c.rat[head.dest] = nil
+		}
+		c.rob = c.rob[1:]
+		c.result.Committed++
+		count++
+	}
+	return count
+}
+
+// write puts finished results on the common data bus, oldest first. Stores
+// and branches produce no register value and do not need the bus.
+func (c *core) write(cycle int) {
+	broadcasts := 0
+	for _, e := range c.rob {
+		if e.ExecEnd == 0 || e.ExecEnd >= cycle || e.Write != 0 {
+			continue
+		}
+		if e.dest != 0 {
+			if broadcasts == c.cfg.CDBWidth {
+				continue
+			}
+			broadcasts++
+		}
+		e.Write = cycle
+		e.hasValue = true
+		c.release(e)
+		for unit := range c.stations {
+			for _, waiting := range c.stations[unit] {
+				waiting.wakeUp(e, cycle)
+			}
+		}
+	}
+}
+
+// wakeUp captures the broadcast of producer; the operand can be used from
+// the next cycle on.
+func (e *Entry) wakeUp(producer *Entry, cycle int) {
+	for i, w := range e.waiting {
+		if w == producer {
+			e.waiting = append(e.waiting[:i], e.waiting[i+1:]...)
+			e.readyAt = max(e.readyAt, cycle+1)
+			return
+		}
+	}
+}
+
+func (e *Entry) waitsFor(producer *Entry) bool {
+	for _, w := range e.waiting {
+		if w == producer {
+			return true
+		}
+	}
+	return false
+}
+
+// release frees the reservation station of e.
+func (c *core) release(e *Entry) {
+	stations := c.stations[e.Unit]
+	for i, s := range stations {
+		if s == e {
+			c.stations[e.Unit] = append(stations[:i], stations[i+1:]...)
+			return
+		}
+	}
+}
+
+// execute starts the ready instructions, oldest first, on the free units.
+func (c *core) execute(cycle int) {
+	for unit := range c.stations {
+		started := 0
+		for _, e := range c.stations[unit] {
+			if started == c.cfg.Units[unit].Units {
+				break
+			}
+			if e.ExecStart != 0 || len(e.waiting) > 0 || e.readyAt > cycle {
+				continue
+			}
+			if e.Instruction.GetMeta().IsLoad && c.storeBefore(e) {
+				continue
+			}
+			e.ExecStart = cycle
+			e.ExecEnd = cycle + c.cfg.Units[unit].Latency - 1
+			started++
+		}
+	}
+}
+
+// storeBefore reports whether a store older than e is still in the
+// reorder buffer.
+func (c *core) storeBefore(e *Entry) bool {
+	for _, older := range c.rob {
+		if older == e {
+			return false
+		}
+		if older.Instruction.GetMeta().IsStore {
+			return true
+		}
+	}
+	return false
+}
+
+// branchPending reports whether a branch or jump in flight has not
+// executed yet, so the next instruction to fetch is unknown.
+func (c *core) branchPending(cycle int) bool {
+	for _, e := range c.rob {
+		if e.Instruction.GetMeta().IsControl() && (e.ExecEnd == 0 || e.ExecEnd >= cycle) {
+			return true
+		}
+	}
+	return false
+}
+
+// issue moves instructions in order into the reorder buffer and the
+// reservation stations, renaming their registers.
+func (c *core) issue(cycle int) int {
+	issued := 0
+	for issued < c.cfg.IssueWidth && c.next < len(c.program) {
+		inst := c.program[c.next]
+		meta := inst.GetMeta()
+		unit := isa.UnitOf(meta)
+		if c.branchPending(cycle) {
+			c.result.Stalls.Branch++
+			break
+		}
+		if len(c.rob) == c.cfg.ROBSize {
+			c.result.Stalls.ROBFull++
+			break
+		}
+		if len(c.stations[unit]) == c.cfg.Units[unit].Stations {
+			c.result.Stalls.StationsFull++
+			break
+		}
+
+		e := &Entry{Index: c.next, Instruction: inst, Unit: unit, Issue: cycle, readyAt: cycle + 1}
+		for _, rs := range meta.Rs {
+			producer := c.rat[rs]
+			if rs == 0 || producer == nil || producer.hasValue || e.waitsFor(producer) {
+				continue
+			}
+			e.waiting = append(e.waiting, producer)
+		}
+		if rd, ok := meta.Dest(); ok {
+			e.dest = rd
+			c.rat[rd] = e
+		}
+
+		c.rob = append(c.rob, e)
+		c.stations[unit] = append(c.stations[unit], e)
+		c.result.Entries = append(c.result.Entries, e)
+		c.next++
+		issued++
+	}
+	return issued
+}
@@ -0,0 +1,98 @@
+// Package trace reads dynamic instruction traces: the retired instruction
+// stream of a real execution, captured from hardware or another simulator.
+package trace
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"riscv-instruction-encoder/pkg/cosim"
+	"strconv"
+	"strings"
+)
+
+// Record is one retired instruction. Taken is only meaningful when
+// HasOutcome is set; otherwise the outcome follows from the next record.
+type Record struct {
+	PC         uint32
+	Insn       uint32
+	Taken      bool
+	HasOutcome bool
+}
+
+// Load reads a trace file. Each line holds either
+//
+//	<pc> <instruction word> [T|N]
+//
+// in hexadecimal, with an optional taken/not-taken flag, or a Spike
+// --log-commits line. Blank lines and '#' comments are ignored.
+func Load(filePath string) ([]Record, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao abrir trace: %w", err)
+	}
+	defer file.Close()
+
+	var records []Record
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		row := scanner.Text()
+		if i := strings.Index(row, "#"); i >= 0 {
+			row = row[:i]
+		}
+		row = strings.TrimSpace(row)
+		if row == "" {
+			continue
+		}
+
+		if strings.HasPrefix(row, "core") {
+			commit, ok, err := cosim.ParseLine(row)
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: %w", filePath, line, err)
+			}
+			if ok {
+				records = append(records, Record{PC: commit.PC, Insn: commit.Insn})
+			}
+			continue
+		}
+
+		record, err := parseRecord(row)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", filePath, line, err)
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+func parseRecord(row string) (Record, error) {
+	fields := strings.Fields(row)
+	if len(fields) < 2 || len(fields) > 3 {
+		return Record{}, fmt.Errorf("registro inválido %q (use: pc instrução [T|N])", row)
+	}
+
+	pc, err := strconv.ParseUint(strings.TrimPrefix(fields[0], "0x"), 16, 32)
+	if err != nil {
+		return Record{}, fmt.Errorf("PC inválido %q", fields[0])
+	}
+	insn, err := strconv.ParseUint(strings.TrimPrefix(fields[1], "0x"), 16, 32)
+	if err != nil {
+		return Record{}, fmt.Errorf("instrução inválida %q", fields[1])
+	}
+
+	record := Record{PC: uint32(pc), Insn: uint32(insn)}
+	if len(fields) == 3 {
+		switch strings.ToUpper(fields[2]) {
+		case "T", "TAKEN":
+			record.Taken = true
+		case "N", "NT", "NOT-TAKEN":
+		default:
+			return Record{}, fmt.Errorf("resultado de desvio inválido %q (use T ou N)", fields[2])
+		}
+		record.HasOutcome = true
+	}
+	return record, nil
+}
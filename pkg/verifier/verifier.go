@@ -0,0 +1,143 @@
+// Package verifier checks that a program runs hazard-free on a pipeline
+// without interlocks, reporting every place where the NOPs or independent
+// instructions between two instructions are not enough. The program is
+// never modified.
+package verifier
+
+import (
+	"riscv-instruction-encoder/pkg/hazard"
+	"riscv-instruction-encoder/pkg/isa"
+	"sort"
+)
+
+type Kind int
+
+const (
+	// RAW is a register read issued before its producer can deliver it.
+	RAW Kind = iota
+	// Control is an instruction fetched while a branch or jump before it
+	// is still unresolved.
+	Control
+	// WAW is a register write that would reach the register file before
+	// the write of a slower instruction issued earlier.
+	WAW
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Control:
+		return "controle"
+	case WAW:
+		return "WAW"
+	}
+	return "RAW"
+}
+
+// Finding is one hazard left in the program. Producer and Consumer are
+// indices into the program; addresses are four times the index.
+type Finding struct {
+	Kind     Kind
+	Producer int
+	Consumer int
+	// Register is the register of a RAW or WAW hazard.
+	Register int
+	// Missing is the number of slots to add between the two instructions.
+	Missing int
+}
+
+// Verify looks for hazards on every path into each instruction: the fall
+// through from the instructions before it and, for branch and jump targets,
+// the instructions before and in the shadow of every control instruction
+// that reaches it.
+func Verify(program []isa.Instruction, cfg hazard.Config) []Finding {
+	v := &verifier{program: program, cfg: cfg, seen: make(map[[2]int]bool)}
+	for b, inst := range program {
+		v.checkShadow(b)
+		if rel, ok := inst.(isa.BranchTarget); ok && inst.GetMeta().IsControl() {
+			t := int(rel.Target(uint32(b*4))) / 4
+			if t >= 0 && t < len(program) {
+				// the target is fetched right after the shadow
+				v.checkData(t, b+v.shadow(b), b)
+			}
+		}
+	}
+	for j := range program {
+		v.checkData(j, j-1, -1)
+	}
+
+	sort.Slice(v.findings, func(a, b int) bool {
+		if v.findings[a].Consumer != v.findings[b].Consumer {
+			return v.findings[a].Consumer < v.findings[b].Consumer
+		}
+		return v.findings[a].Producer < v.findings[b].Producer
+	})
+	return v.findings
+}
+
+type verifier struct {
+	program  []isa.Instruction
+	cfg      hazard.Config
+	findings []Finding
+	seen     map[[2]int]bool
+}
+
+// shadow is the number of slots after instruction b that are fetched before
+// it resolves.
+func (v *verifier) shadow(b int) int {
+	return hazard.ControlDistance(v.program[b], v.cfg) - 1
+}
+
+// checkShadow requires the slots after a control instruction to be NOPs.
+func (v *verifier) checkShadow(b int) {
+	slots := v.shadow(b)
+	for s := 1; s <= slots && b+s < len(v.program); s++ {
+		if !isa.IsNOP(v.program[b+s]) {
+			v.add(Finding{Kind: Control, Producer: b, Consumer: b + s, Missing: slots - s + 1})
+			return
+		}
+	}
+}
+
+// checkData compares instruction j with the instructions issued before it,
+// the closest being last, on the path that reaches j through the control
+// instruction via (-1 for the fall through). Walking back stops past the
+// pipeline depth and at any other jump whose shadow ends before j, since
+// the path continues at the jump's target instead.
+func (v *verifier) checkData(j, last, via int) {
+	consumer := v.program[j]
+	p, distance := last, 1
+	if p >= len(v.program) {
+		// the shadow runs past the end of the program
+		p, distance = len(v.program)-1, 1+last-(len(v.program)-1)
+	}
+	for ; p >= 0 && distance < v.cfg.Depth(); p, distance = p-1, distance+1 {
+		producer := v.program[p]
+		meta := producer.GetMeta()
+		if meta.IsJump && p != via && p+v.shadow(p) <= last {
+			return
+		}
+		rd, ok := meta.Dest()
+		if !ok {
+			continue
+		}
+		kind := RAW
+		if !consumer.GetMeta().Reads(rd) {
+			if dest, ok := consumer.GetMeta().Dest(); !ok || dest != rd {
+				continue
+			}
+			kind = WAW
+		}
+		if missing := hazard.MinDistance(producer, consumer, v.cfg) - distance; missing > 0 {
+			v.add(Finding{Kind: kind, Producer: p, Consumer: j, Register: rd, Missing: missing})
+		}
+	}
+}
+
+func (v *verifier) add(f Finding) {
+	key := [2]int{f.Producer, f.Consumer}
+	if v.seen[key] {
+		return
+	}
+	v.seen[key] = true
+	v.findings = append(v.findings, f)
+}